@@ -0,0 +1,123 @@
+package neuro
+
+import (
+	"time"
+)
+
+// Action Availability
+
+// AvailabilityHandler is an ActionHandler that only exists while the game allows it,
+// such as a "buy" action while the player is in a shop. Unavailable actions stay
+// registered with the client but are not sent to Neuro until Tick finds them
+// available, and are unregistered from Neuro once Tick finds them unavailable.
+type AvailabilityHandler interface {
+	ActionHandler
+	// IsAvailable reports whether the action can currently be used
+	IsAvailable() bool
+}
+
+// isAvailable reports whether a handler is available, treating handlers
+// without a predicate as always available
+func isAvailable(h ActionHandler) bool {
+	if a, ok := h.(AvailabilityHandler); ok {
+		return a.IsAvailable()
+	}
+	return true
+}
+
+// WhenAvailable wraps a handler with an availability predicate
+func WhenAvailable(handler ActionHandler, predicate func() bool) AvailabilityHandler {
+	return &conditionalHandler{ActionHandler: handler, predicate: predicate}
+}
+
+// conditionalHandler adds an availability predicate to a handler
+type conditionalHandler struct {
+	ActionHandler
+	predicate func() bool
+}
+
+func (h *conditionalHandler) IsAvailable() bool {
+	if a, ok := h.ActionHandler.(AvailabilityHandler); ok && !a.IsAvailable() {
+		return false
+	}
+	return h.predicate()
+}
+
+// HasDynamicSchema forwards to the wrapped handler so wrapping keeps it refreshable
+func (h *conditionalHandler) HasDynamicSchema() bool {
+	d, ok := h.ActionHandler.(DynamicSchemaHandler)
+	return ok && d.HasDynamicSchema()
+}
+
+// Tick re-evaluates every AvailabilityHandler and updates Neuro with the actions
+// that became available or unavailable since the last check. Call it whenever game
+// state changes, or set ClientConfig.AvailabilityInterval to call it periodically.
+func (c *Client) Tick() error {
+	c.actionsMu.Lock()
+
+	var hide []string
+	var show []ActionDefinition
+	for _, name := range sortedKeys(c.actions) {
+		h := c.actions[name]
+		if _, ok := h.(AvailabilityHandler); !ok {
+			continue
+		}
+
+		_, sent := c.sent[name]
+		available := isAvailable(h)
+		switch {
+		case available && !sent:
+			show = append(show, ActionDefinition{
+				Name:        name,
+				Description: h.GetDescription(),
+				Schema:      h.GetSchema(),
+			})
+		case !available && sent:
+			hide = append(hide, name)
+		}
+	}
+
+	if len(hide) == 0 && len(show) == 0 {
+		c.actionsMu.Unlock()
+		return nil
+	}
+
+	c.logger.Info("Updating action availability", "available", len(show), "unavailable", len(hide))
+
+	var err error
+	if len(hide) > 0 {
+		err = c.sendUnregister(hide)
+	}
+	if err == nil && len(show) > 0 {
+		err = c.sendRegister(show)
+	}
+	c.actionsMu.Unlock()
+
+	if len(hide) > 0 {
+		c.supersedeStaleForce()
+	}
+	return err
+}
+
+// tickLoop calls Tick every interval until the client is closed
+func (c *Client) tickLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.connMu.RLock()
+			connected := c.connected
+			c.connMu.RUnlock()
+			if !connected {
+				continue
+			}
+			if err := c.Tick(); err != nil {
+				c.logger.Warn("Failed to update action availability", "error", err)
+			}
+		case <-c.closeChan:
+			return
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package neuro
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// unregisteredNames reads the next message as an unregistration and returns its action names
+func unregisteredNames(t *testing.T, conn *testConn) []string {
+	t.Helper()
+
+	var data UnregisterActionsData
+	if err := json.Unmarshal(conn.expect(CommandUnregisterActions).Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	return data.ActionNames
+}
+
+// expectNothingSent checks that the next message is a context sent after the step under test
+func expectNothingSent(t *testing.T, c *Client, conn *testConn) {
+	t.Helper()
+
+	if err := c.SendContext("marker", true); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandContext)
+}
+
+func TestTickTogglesRegistration(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	var open atomic.Bool
+	shop := WhenAvailable(&testAction{name: "buy"}, open.Load)
+	if err := c.RegisterActions([]ActionHandler{shop, &testAction{name: "jump"}}); err != nil {
+		t.Fatal(err)
+	}
+	if names := registeredNames(t, conn); !reflect.DeepEqual(names, []string{"jump"}) {
+		t.Fatalf("registered %v, want only the available action", names)
+	}
+
+	steps := []struct {
+		name         string
+		open         bool
+		registered   []string
+		unregistered []string
+	}{
+		{"still unavailable", false, nil, nil},
+		{"becomes available", true, []string{"buy"}, nil},
+		{"stays available", true, nil, nil},
+		{"becomes unavailable", false, nil, []string{"buy"}},
+		{"available again", true, []string{"buy"}, nil},
+	}
+
+	for _, step := range steps {
+		open.Store(step.open)
+		if err := c.Tick(); err != nil {
+			t.Fatalf("%s: Tick() = %v", step.name, err)
+		}
+		switch {
+		case step.registered != nil:
+			if names := registeredNames(t, conn); !reflect.DeepEqual(names, step.registered) {
+				t.Errorf("%s: registered %v, want %v", step.name, names, step.registered)
+			}
+		case step.unregistered != nil:
+			if names := unregisteredNames(t, conn); !reflect.DeepEqual(names, step.unregistered) {
+				t.Errorf("%s: unregistered %v, want %v", step.name, names, step.unregistered)
+			}
+		default:
+			expectNothingSent(t, c, conn)
+		}
+	}
+}
+
+func TestUnavailableActionFails(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	var open atomic.Bool
+	open.Store(true)
+	if err := c.RegisterAction(WhenAvailable(&testAction{name: "buy"}, open.Load)); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+
+	// Neuro may still send the action before Tick unregisters it
+	open.Store(false)
+	conn.sendAction("1", "buy", "")
+	if result := conn.expectResult(); result.Success || !strings.Contains(result.Message, "not available") {
+		t.Errorf("result = %+v, want a failure for the unavailable action", result)
+	}
+}
+
+func TestTickDropsStaleForce(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	var open atomic.Bool
+	open.Store(true)
+	if err := c.RegisterAction(WhenAvailable(&testAction{name: "buy"}, open.Load)); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.ForceAndWait(context.Background(), "Buy something", []string{"buy"})
+		done <- err
+	}()
+	readForce(t, conn)
+
+	// Once its only action is hidden, nothing can answer the force
+	open.Store(false)
+	if err := c.Tick(); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandUnregisterActions)
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrForceStale) {
+			t.Errorf("ForceAndWait() = %v, want %v", err, ErrForceStale)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("ForceAndWait did not return")
+	}
+	if f := c.ActiveForce(); f != nil {
+		t.Errorf("active force = %+v after its action was hidden", f)
+	}
+}
+
+func TestAvailabilityInterval(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, func(config *ClientConfig) {
+		config.AvailabilityInterval = 10 * time.Millisecond
+	})
+	conn := connect(t, c, s)
+
+	var open atomic.Bool
+	if err := c.RegisterAction(WhenAvailable(&testAction{name: "buy"}, open.Load)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The periodic Tick picks up the change without being called
+	open.Store(true)
+	if names := registeredNames(t, conn); !reflect.DeepEqual(names, []string{"buy"}) {
+		t.Errorf("registered %v, want [buy]", names)
+	}
+	open.Store(false)
+	if names := unregisteredNames(t, conn); !reflect.DeepEqual(names, []string{"buy"}) {
+		t.Errorf("unregistered %v, want [buy]", names)
+	}
+}
+
+func TestWhenAvailableWrapsPredicates(t *testing.T) {
+	var inner, outer atomic.Bool
+	h := WhenAvailable(WhenAvailable(&testAction{name: "buy"}, inner.Load), outer.Load)
+
+	for _, tt := range []struct{ inner, outer, want bool }{
+		{false, false, false},
+		{true, false, false},
+		{false, true, false},
+		{true, true, true},
+	} {
+		inner.Store(tt.inner)
+		outer.Store(tt.outer)
+		if got := h.IsAvailable(); got != tt.want {
+			t.Errorf("IsAvailable() with inner %v and outer %v = %v, want %v", tt.inner, tt.outer, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Command neuro-manifest exports action manifests as canonical JSON and diffs
+// them against a committed golden file, so code review shows how the actions
+// sent to Neuro changed and CI can fail on accidental changes:
+//
+//	neuro-manifest -golden actions.golden.json actions.yaml shop.yaml
+//
+// Manifests are linted first. Without -golden the export is printed. With
+// -update the golden file is rewritten. The exit status is 1 if the export
+// differs from the golden file and 2 on other errors.
+//
+// Only manifest files are supported. The command cannot load actions defined
+// in Go code, since it cannot import a game's packages; export those in a test
+// of the game with neuro.ExportActions or Client.ExportActions and check them
+// with neuro.CheckActionsGolden.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cassitly/neuro-integration-sdk"
+)
+
+func main() {
+	golden := flag.String("golden", "", "golden file to compare the export with")
+	update := flag.Bool("update", false, "write the export to the golden file instead of comparing")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] manifest...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *update && *golden == "" {
+		fail(errors.New("-update requires -golden"))
+	}
+
+	var defs []neuro.ActionDefinition
+	var handlers []neuro.ActionHandler
+	for _, path := range flag.Args() {
+		m, err := neuro.LoadManifest(path)
+		if err != nil {
+			fail(err)
+		}
+		bound, err := m.Bind(noopFuncs(m))
+		if err != nil {
+			fail(fmt.Errorf("%s: %w", path, err))
+		}
+		defs = append(defs, m.Definitions()...)
+		handlers = append(handlers, bound...)
+	}
+	// Each manifest was linted by Bind; this catches actions defined in more than one
+	// and prints the warnings, which do not fail the export
+	issues := neuro.LintActions(defs)
+	for _, issue := range issues {
+		if issue.Warning {
+			fmt.Fprintln(os.Stderr, "neuro-manifest:", issue)
+		}
+	}
+	for _, issue := range neuro.LintErrors(issues) {
+		fail(issue)
+	}
+
+	export, err := neuro.ExportActions(handlers)
+	if err != nil {
+		fail(err)
+	}
+
+	if *golden == "" {
+		os.Stdout.Write(export)
+		return
+	}
+
+	err = neuro.CheckActionsGolden(*golden, export, *update)
+	if errors.Is(err, neuro.ErrGoldenMismatch) {
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, "Run with -update if the change is intended.")
+		os.Exit(1)
+	}
+	if err != nil {
+		fail(err)
+	}
+	if *update {
+		fmt.Fprintf(os.Stderr, "Updated %s with %d action(s)\n", *golden, len(defs))
+	}
+}
+
+// noopFuncs binds every handler named in m to a func that does nothing
+func noopFuncs(m *neuro.Manifest) map[string]neuro.ActionFunc {
+	funcs := make(map[string]neuro.ActionFunc, len(m.Actions))
+	for _, a := range m.Actions {
+		funcs[a.HandlerName()] = func(json.RawMessage) error { return nil }
+	}
+	return funcs
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "neuro-manifest:", err)
+	os.Exit(2)
+}
@@ -0,0 +1,274 @@
+// Command neuro-proxy is a man-in-the-middle websocket proxy for inspecting
+// traffic between a game and Neuro (or a stand-in backend).
+//
+// Every message is decoded with the SDK's message types, pretty-printed and
+// checked against the protocol. Messages can be dropped by command or at
+// random, and new ones can be injected from stdin:
+//
+//	game {"command":"action","data":{"id":"1","name":"greet"}}
+//	neuro {"command":"context","game":"My Game","data":{"message":"hi","silent":true}}
+//
+// Lines starting with "game" are sent to the game as if they came from Neuro,
+// lines starting with "neuro" are sent to the backend as if they came from the game.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cassitly/neuro-integration-sdk"
+	"github.com/gorilla/websocket"
+)
+
+func main() {
+	listen := flag.String("listen", "127.0.0.1:8001", "address for the game to connect to")
+	upstream := flag.String("upstream", "ws://localhost:8000", "websocket URL of Neuro or a stand-in backend")
+	drop := flag.String("drop", "", "comma-separated commands to drop in either direction")
+	dropRate := flag.Float64("drop-rate", 0, "probability (0-1) of dropping any message")
+	raw := flag.Bool("raw", false, "print messages as received instead of pretty-printing")
+	flag.Parse()
+
+	p := &proxy{
+		upstream: *upstream,
+		drop:     make(map[string]bool),
+		dropRate: *dropRate,
+		raw:      *raw,
+		out:      log.New(os.Stdout, "", log.Ltime|log.Lmicroseconds),
+	}
+	for _, cmd := range strings.Split(*drop, ",") {
+		if cmd = strings.TrimSpace(cmd); cmd != "" {
+			p.drop[cmd] = true
+		}
+	}
+
+	go p.readInjections(os.Stdin)
+
+	p.out.Printf("Listening on ws://%s, forwarding to %s", *listen, *upstream)
+	if err := http.ListenAndServe(*listen, p); err != nil {
+		log.Fatal(err)
+	}
+}
+
+type proxy struct {
+	upstream string
+	drop     map[string]bool
+	dropRate float64
+	raw      bool
+	out      *log.Logger
+
+	mu      sync.Mutex
+	session *session
+}
+
+// session is a single game connection and its upstream counterpart
+type session struct {
+	game    *websocket.Conn
+	neuro   *websocket.Conn
+	gameMu  sync.Mutex
+	neuroMu sync.Mutex
+	checker *neuro.ProtocolChecker
+}
+
+func (p *proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	gameConn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		p.out.Printf("Failed to accept game connection: %v", err)
+		return
+	}
+	defer gameConn.Close()
+
+	neuroConn, _, err := websocket.DefaultDialer.Dial(p.upstream, nil)
+	if err != nil {
+		p.out.Printf("Failed to connect to upstream: %v", err)
+		return
+	}
+	defer neuroConn.Close()
+
+	s := &session{
+		game:    gameConn,
+		neuro:   neuroConn,
+		checker: neuro.NewProtocolChecker(),
+	}
+
+	p.mu.Lock()
+	p.session = s
+	p.mu.Unlock()
+
+	p.out.Printf("Game connected from %s", r.RemoteAddr)
+
+	done := make(chan struct{}, 2)
+	go func() {
+		p.pump(s, neuro.DirectionOutbound, gameConn, neuroConn, &s.neuroMu)
+		done <- struct{}{}
+	}()
+	go func() {
+		p.pump(s, neuro.DirectionInbound, neuroConn, gameConn, &s.gameMu)
+		done <- struct{}{}
+	}()
+	<-done
+
+	p.mu.Lock()
+	if p.session == s {
+		p.session = nil
+	}
+	p.mu.Unlock()
+
+	p.out.Printf("Session closed")
+}
+
+// pump forwards messages from src to dst until either side closes
+func (p *proxy) pump(s *session, direction neuro.Direction, src, dst *websocket.Conn, dstMu *sync.Mutex) {
+	for {
+		msgType, msgBytes, err := src.ReadMessage()
+		if err != nil {
+			p.out.Printf("%s read error: %v", direction, err)
+			dst.Close()
+			return
+		}
+
+		if !p.inspect(s, direction, msgBytes, false) {
+			continue
+		}
+
+		dstMu.Lock()
+		err = dst.WriteMessage(msgType, msgBytes)
+		dstMu.Unlock()
+		if err != nil {
+			p.out.Printf("%s write error: %v", direction, err)
+			src.Close()
+			return
+		}
+	}
+}
+
+// inspect prints and checks a message, returning false if it should be dropped
+func (p *proxy) inspect(s *session, direction neuro.Direction, msgBytes []byte, injected bool) bool {
+	arrow := "game -> neuro"
+	if direction == neuro.DirectionInbound {
+		arrow = "neuro -> game"
+	}
+	if injected {
+		arrow += " [injected]"
+	}
+
+	var msg neuro.Message
+	if err := json.Unmarshal(msgBytes, &msg); err != nil {
+		p.out.Printf("%s undecodable message: %v\n%s", arrow, err, msgBytes)
+		return true
+	}
+
+	if !injected && (p.drop[msg.Command] || (p.dropRate > 0 && rand.Float64() < p.dropRate)) {
+		p.out.Printf("%s %s [dropped]", arrow, msg.Command)
+		return false
+	}
+
+	if p.raw {
+		p.out.Printf("%s %s", arrow, msgBytes)
+	} else {
+		p.out.Printf("%s %s%s", arrow, msg.Command, prettyData(msg))
+	}
+
+	for _, v := range s.checker.Check(direction, msg) {
+		p.out.Printf("  !! protocol violation: %s", v.Message)
+	}
+
+	return true
+}
+
+// prettyData renders the message data indented, decoding stringified action parameters
+func prettyData(msg neuro.Message) string {
+	if len(msg.Data) == 0 {
+		return ""
+	}
+
+	var data interface{} = msg.Data
+	if msg.Command == neuro.CommandAction {
+		var action neuro.IncomingAction
+		if err := json.Unmarshal(msg.Data, &action); err == nil {
+			params := json.RawMessage(action.Data)
+			if action.Data == "" || !json.Valid(params) {
+				params = nil
+			}
+			data = struct {
+				ID     string          `json:"id"`
+				Name   string          `json:"name"`
+				Params json.RawMessage `json:"params,omitempty"`
+			}{action.ID, action.Name, params}
+		}
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return " " + string(msg.Data)
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, b, "  ", "  "); err != nil {
+		return " " + string(msg.Data)
+	}
+	return "\n  " + buf.String()
+}
+
+// readInjections reads "game <json>" and "neuro <json>" lines and sends them into the active session
+func (p *proxy) readInjections(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		target, payload, _ := strings.Cut(line, " ")
+		if err := p.inject(target, []byte(strings.TrimSpace(payload))); err != nil {
+			p.out.Printf("Injection failed: %v", err)
+		}
+	}
+}
+
+func (p *proxy) inject(target string, payload []byte) error {
+	p.mu.Lock()
+	s := p.session
+	p.mu.Unlock()
+
+	if s == nil {
+		return fmt.Errorf("no active session")
+	}
+
+	var msg neuro.Message
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return fmt.Errorf("invalid message: %w", err)
+	}
+
+	var (
+		direction neuro.Direction
+		conn      *websocket.Conn
+		mu        *sync.Mutex
+	)
+	switch target {
+	case "game":
+		direction, conn, mu = neuro.DirectionInbound, s.game, &s.gameMu
+	case "neuro":
+		direction, conn, mu = neuro.DirectionOutbound, s.neuro, &s.neuroMu
+	default:
+		return fmt.Errorf("unknown target %q, expected \"game\" or \"neuro\"", target)
+	}
+
+	p.inspect(s, direction, payload, true)
+
+	mu.Lock()
+	defer mu.Unlock()
+	conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	defer conn.SetWriteDeadline(time.Time{})
+	return conn.WriteMessage(websocket.TextMessage, payload)
+}
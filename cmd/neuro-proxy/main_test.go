@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cassitly/neuro-integration-sdk"
+	"github.com/gorilla/websocket"
+)
+
+// syncBuffer collects the proxy output from several goroutines
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// testSession connects a game to a proxy in front of a stand-in backend
+type testSession struct {
+	t     *testing.T
+	proxy *proxy
+	out   *syncBuffer
+	game  *websocket.Conn
+	neuro *websocket.Conn
+}
+
+func newTestSession(t *testing.T, configure func(*proxy)) *testSession {
+	t.Helper()
+
+	upstream := make(chan *websocket.Conn, 1)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		upstream <- conn
+	}))
+	t.Cleanup(backend.Close)
+
+	out := &syncBuffer{}
+	p := &proxy{
+		upstream: "ws" + strings.TrimPrefix(backend.URL, "http"),
+		drop:     make(map[string]bool),
+		out:      log.New(out, "", 0),
+	}
+	if configure != nil {
+		configure(p)
+	}
+	srv := httptest.NewServer(p)
+	t.Cleanup(srv.Close)
+
+	game, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { game.Close() })
+
+	s := &testSession{t: t, proxy: p, out: out, game: game}
+	select {
+	case s.neuro = <-upstream:
+		t.Cleanup(func() { s.neuro.Close() })
+	case <-time.After(2 * time.Second):
+		t.Fatal("proxy did not connect upstream")
+	}
+
+	// The session is set once both sides are connected
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		p.mu.Lock()
+		ready := p.session != nil
+		p.mu.Unlock()
+		if ready {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("proxy session was not set up")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return s
+}
+
+// read returns the command of the next message on conn
+func (s *testSession) read(conn *websocket.Conn) string {
+	s.t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg neuro.Message
+	if err := conn.ReadJSON(&msg); err != nil {
+		s.t.Fatalf("failed to read message: %v", err)
+	}
+	return msg.Command
+}
+
+func (s *testSession) write(conn *websocket.Conn, msg string) {
+	s.t.Helper()
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+const (
+	startupMsg  = `{"command":"startup","game":"Test"}`
+	contextMsg  = `{"command":"context","game":"Test","data":{"message":"hi","silent":true}}`
+	registerMsg = `{"command":"actions/register","game":"Test","data":{"actions":[{"name":"greet","description":"Greet"}]}}`
+	actionMsg   = `{"command":"action","data":{"id":"1","name":"greet"}}`
+)
+
+func TestProxyForwards(t *testing.T) {
+	s := newTestSession(t, func(p *proxy) { p.drop[neuro.CommandContext] = true })
+
+	s.write(s.game, startupMsg)
+	if got := s.read(s.neuro); got != neuro.CommandStartup {
+		t.Errorf("backend received %s, want startup", got)
+	}
+
+	// Dropped commands never reach the other side
+	s.write(s.game, contextMsg)
+	s.write(s.game, registerMsg)
+	if got := s.read(s.neuro); got != neuro.CommandRegisterActions {
+		t.Errorf("backend received %s, want the context to be dropped", got)
+	}
+
+	s.write(s.neuro, actionMsg)
+	if got := s.read(s.game); got != neuro.CommandAction {
+		t.Errorf("game received %s, want action", got)
+	}
+
+	out := s.out.String()
+	for _, want := range []string{
+		"game -> neuro startup",
+		"game -> neuro context [dropped]",
+		"neuro -> game action",
+		`"name": "greet"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestProxyReportsViolations(t *testing.T) {
+	s := newTestSession(t, nil)
+
+	// An empty context breaks the protocol but is still forwarded
+	s.write(s.game, `{"command":"context","game":"Test","data":{"message":"","silent":true}}`)
+	if got := s.read(s.neuro); got != neuro.CommandContext {
+		t.Errorf("backend received %s, want context", got)
+	}
+	if out := s.out.String(); !strings.Contains(out, "!! protocol violation: empty context message") {
+		t.Errorf("no violation reported:\n%s", out)
+	}
+}
+
+func TestProxyInject(t *testing.T) {
+	s := newTestSession(t, nil)
+
+	tests := []struct {
+		name   string
+		target string
+		msg    string
+		conn   *websocket.Conn
+		ok     bool
+	}{
+		{"to game", "game", actionMsg, s.game, true},
+		{"to neuro", "neuro", startupMsg, s.neuro, true},
+		{"unknown target", "both", startupMsg, nil, false},
+		{"invalid message", "game", "{", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.proxy.inject(tt.target, []byte(tt.msg))
+			if (err == nil) != tt.ok {
+				t.Fatalf("inject() = %v, want ok %v", err, tt.ok)
+			}
+			if tt.conn != nil {
+				s.read(tt.conn)
+			}
+		})
+	}
+	if !strings.Contains(s.out.String(), "[injected]") {
+		t.Errorf("injections are not marked:\n%s", s.out.String())
+	}
+
+	// Injections from stdin are sent into the session as well
+	s.proxy.readInjections(strings.NewReader("\ngame " + actionMsg + "\n"))
+	if got := s.read(s.game); got != neuro.CommandAction {
+		t.Errorf("game received %s, want the injected action", got)
+	}
+}
+
+func TestProxyInjectWithoutSession(t *testing.T) {
+	p := &proxy{out: log.New(&syncBuffer{}, "", 0)}
+	if err := p.inject("game", []byte(actionMsg)); err == nil {
+		t.Error("inject() succeeded without a session")
+	}
+}
+
+func TestPrettyData(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		want string
+	}{
+		{"no data", startupMsg, ""},
+		{"indented", `{"command":"context","data":{"message":"hi"}}`, "\n  {\n    \"message\": \"hi\"\n  }"},
+		{"action params", `{"command":"action","data":{"id":"1","name":"buy","data":"{\"item\":\"sword\"}"}}`,
+			"\n  {\n    \"id\": \"1\",\n    \"name\": \"buy\",\n    \"params\": {\n      \"item\": \"sword\"\n    }\n  }"},
+		{"invalid params", `{"command":"action","data":{"id":"1","name":"buy","data":"{"}}`,
+			"\n  {\n    \"id\": \"1\",\n    \"name\": \"buy\"\n  }"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var msg neuro.Message
+			if err := json.Unmarshal([]byte(tt.msg), &msg); err != nil {
+				t.Fatal(err)
+			}
+			if got := prettyData(msg); got != tt.want {
+				t.Errorf("prettyData() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
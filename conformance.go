@@ -0,0 +1,66 @@
+package neuro
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Protocol Conformance
+
+// ConformanceMode controls how the client reacts to protocol violations in its own messages
+type ConformanceMode int
+
+const (
+	// ConformanceOff sends every message without checking it
+	ConformanceOff ConformanceMode = iota
+	// ConformanceWarn logs violations but still sends the message
+	ConformanceWarn
+	// ConformanceStrict refuses to send messages with violations and returns a *ConformanceError
+	ConformanceStrict
+)
+
+// ConformanceError is returned when strict conformance rejects an outbound message
+type ConformanceError struct {
+	Command    string
+	Violations []Violation
+}
+
+func (e *ConformanceError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.Message
+	}
+	return fmt.Sprintf("protocol violation in %s: %s", e.Command, strings.Join(msgs, "; "))
+}
+
+// checkOutbound runs an outbound message through the conformance checker.
+// It returns a *ConformanceError if the message must not be sent. Otherwise
+// commit must be called once the message was written, so a failed write
+// leaves the checker's state untouched.
+func (c *Client) checkOutbound(msg Message) (commit func(), err error) {
+	if c.config.Conformance == ConformanceOff {
+		return func() {}, nil
+	}
+
+	violations, commit := c.conformance.Prepare(DirectionOutbound, msg)
+	if c.config.Conformance == ConformanceStrict && len(violations) > 0 {
+		for _, v := range violations {
+			c.logger.Warn("Rejected protocol violation", "command", msg.Command, "violation", v.Message)
+		}
+		return nil, &ConformanceError{Command: msg.Command, Violations: violations}
+	}
+	for _, v := range violations {
+		c.logger.Warn("Protocol violation", "command", msg.Command, "violation", v.Message)
+	}
+	return commit, nil
+}
+
+// observeInbound tracks an inbound message so results and forces can be checked against it
+func (c *Client) observeInbound(msg Message) {
+	if c.config.Conformance == ConformanceOff {
+		return
+	}
+	for _, v := range c.conformance.Check(DirectionInbound, msg) {
+		c.logger.Warn("Protocol violation from Neuro", "command", msg.Command, "violation", v.Message)
+	}
+}
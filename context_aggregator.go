@@ -0,0 +1,208 @@
+package neuro
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Context Aggregation
+
+// ContextLine is a distinct context message within a batch
+type ContextLine struct {
+	Message string
+	// Count is how many times the message was sent during the batch window
+	Count int
+}
+
+// ContextAggregatorConfig configures a ContextAggregator
+type ContextAggregatorConfig struct {
+	// Window is how long messages are collected before being sent as one (default 1s)
+	Window time.Duration
+	// MaxLines flushes a batch early once it holds this many distinct lines (default 20)
+	MaxLines int
+	// Summarize turns a batch into a single context message (default joins lines, marking repeats)
+	Summarize func(lines []ContextLine) string
+}
+
+// ContextAggregator batches context messages so fast-paced games do not flood Neuro.
+// Messages sent within a window are deduplicated and collapsed into one message.
+// Silent and non-silent messages are batched separately so each batch keeps its flag.
+type ContextAggregator struct {
+	client *Client
+	config ContextAggregatorConfig
+
+	mu      sync.Mutex
+	batches [2]*contextBatch
+	closed  bool
+	// sendMu is taken before mu is released when a batch is taken, so batches
+	// are sent in the order they were taken. It is never held while taking mu.
+	sendMu sync.Mutex
+}
+
+type contextBatch struct {
+	silent bool
+	lines  []ContextLine
+	index  map[string]int
+	timer  *time.Timer
+}
+
+// NewContextAggregator creates a context aggregator that sends through this client
+func (c *Client) NewContextAggregator(config ContextAggregatorConfig) *ContextAggregator {
+	if config.Window <= 0 {
+		config.Window = time.Second
+	}
+	if config.MaxLines <= 0 {
+		config.MaxLines = 20
+	}
+	if config.Summarize == nil {
+		config.Summarize = SummarizeContextLines
+	}
+
+	return &ContextAggregator{
+		client: c,
+		config: config,
+	}
+}
+
+// SendContext queues a context message for the current batch
+func (a *ContextAggregator) SendContext(message string, silent bool) error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return errors.New("context aggregator is closed")
+	}
+
+	slot := batchSlot(silent)
+	b := a.batches[slot]
+	if b == nil {
+		b = &contextBatch{
+			silent: silent,
+			index:  make(map[string]int),
+		}
+		b.timer = time.AfterFunc(a.config.Window, func() { a.flushBatch(b) })
+		a.batches[slot] = b
+	}
+
+	if i, ok := b.index[message]; ok {
+		b.lines[i].Count++
+		a.mu.Unlock()
+		return nil
+	}
+	b.index[message] = len(b.lines)
+	b.lines = append(b.lines, ContextLine{Message: message, Count: 1})
+
+	if len(b.lines) < a.config.MaxLines {
+		a.mu.Unlock()
+		return nil
+	}
+
+	// A full batch is sent right away, and before any batch taken after it
+	b.timer.Stop()
+	a.batches[slot] = nil
+	a.sendMu.Lock()
+	a.mu.Unlock()
+	defer a.sendMu.Unlock()
+	return a.send(b)
+}
+
+// SendPriority sends a context message immediately, bypassing batching
+func (a *ContextAggregator) SendPriority(message string, silent bool) error {
+	return a.client.SendContext(message, silent)
+}
+
+// Flush sends all pending batches now
+func (a *ContextAggregator) Flush() error {
+	a.mu.Lock()
+	pending := a.takeAll()
+	a.sendMu.Lock()
+	a.mu.Unlock()
+	defer a.sendMu.Unlock()
+
+	var errs []error
+	for _, b := range pending {
+		if err := a.send(b); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close flushes pending batches and stops accepting messages
+func (a *ContextAggregator) Close() error {
+	a.mu.Lock()
+	a.closed = true
+	pending := a.takeAll()
+	a.sendMu.Lock()
+	a.mu.Unlock()
+	defer a.sendMu.Unlock()
+
+	var errs []error
+	for _, b := range pending {
+		if err := a.send(b); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// takeAll removes and returns all pending batches. Must be called with a.mu held.
+func (a *ContextAggregator) takeAll() []*contextBatch {
+	var pending []*contextBatch
+	for i, b := range a.batches {
+		if b != nil {
+			b.timer.Stop()
+			pending = append(pending, b)
+			a.batches[i] = nil
+		}
+	}
+	return pending
+}
+
+// flushBatch sends a batch when its window ends, unless it was already taken
+func (a *ContextAggregator) flushBatch(b *contextBatch) {
+	a.mu.Lock()
+	slot := batchSlot(b.silent)
+	if a.batches[slot] != b {
+		a.mu.Unlock()
+		return
+	}
+	a.batches[slot] = nil
+	a.sendMu.Lock()
+	a.mu.Unlock()
+	defer a.sendMu.Unlock()
+
+	a.send(b)
+}
+
+// send sends a batch as one context message. Must be called with a.sendMu held.
+func (a *ContextAggregator) send(b *contextBatch) error {
+	message := a.config.Summarize(b.lines)
+	if err := a.client.SendContext(message, b.silent); err != nil {
+		a.client.logger.Error("Failed to send batched context", "lines", len(b.lines), "error", err)
+		return err
+	}
+	return nil
+}
+
+func batchSlot(silent bool) int {
+	if silent {
+		return 1
+	}
+	return 0
+}
+
+// SummarizeContextLines joins distinct lines with newlines, marking repeated ones with their count
+func SummarizeContextLines(lines []ContextLine) string {
+	parts := make([]string, len(lines))
+	for i, l := range lines {
+		if l.Count > 1 {
+			parts[i] = fmt.Sprintf("%s (x%d)", l.Message, l.Count)
+		} else {
+			parts[i] = l.Message
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,63 @@
+package neuro
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// expectContext reads the next message as context
+func expectContext(t *testing.T, conn *testConn) ContextData {
+	t.Helper()
+
+	var data ContextData
+	if err := json.Unmarshal(conn.expect(CommandContext).Data, &data); err != nil {
+		t.Fatalf("invalid context: %v", err)
+	}
+	return data
+}
+
+func TestContextAggregatorFullBatchesKeepOrder(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	a := c.NewContextAggregator(ContextAggregatorConfig{Window: time.Hour, MaxLines: 2})
+	for i := 0; i < 20; i++ {
+		if err := a.SendContext(string(rune('a'+i)), true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < 20; i += 2 {
+		want := string(rune('a'+i)) + "\n" + string(rune('a'+i+1))
+		if got := expectContext(t, conn); got.Message != want {
+			t.Fatalf("batch %d = %q, want %q", i/2, got.Message, want)
+		}
+	}
+}
+
+func TestContextAggregatorWindow(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	a := c.NewContextAggregator(ContextAggregatorConfig{Window: 20 * time.Millisecond})
+	for _, message := range []string{"Hit", "Hit", "Miss", "Hit"} {
+		if err := a.SendContext(message, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := expectContext(t, conn)
+	if got.Message != "Hit (x3)\nMiss" || got.Silent {
+		t.Errorf("batch = %+v, want repeats collapsed and not silent", got)
+	}
+
+	if err := a.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SendContext("late", false); err == nil {
+		t.Error("SendContext() succeeded after Close()")
+	}
+}
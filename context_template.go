@@ -0,0 +1,171 @@
+package neuro
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"unicode/utf8"
+)
+
+// Context Templates
+
+// contextTemplate is a registered event template
+type contextTemplate struct {
+	tmpl   *template.Template
+	silent bool
+}
+
+// contextTemplates holds templates keyed by event type
+type contextTemplates struct {
+	mu        sync.RWMutex
+	templates map[string]contextTemplate
+}
+
+// RegisterContextTemplate parses a text/template and uses it to render context
+// for the given event type in SendEvent
+func (c *Client) RegisterContextTemplate(event string, text string, silent bool) error {
+	tmpl, err := template.New(event).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid template for event %q: %w", event, err)
+	}
+	c.SetContextTemplate(event, tmpl, silent)
+	return nil
+}
+
+// SetContextTemplate registers an already parsed template for an event type
+func (c *Client) SetContextTemplate(event string, tmpl *template.Template, silent bool) {
+	c.templates.mu.Lock()
+	defer c.templates.mu.Unlock()
+
+	if c.templates.templates == nil {
+		c.templates.templates = make(map[string]contextTemplate)
+	}
+	c.templates.templates[event] = contextTemplate{tmpl: tmpl, silent: silent}
+}
+
+// RenderEvent renders the template for an event type without sending it
+func (c *Client) RenderEvent(event string, data interface{}) (string, error) {
+	c.templates.mu.RLock()
+	t, ok := c.templates.templates[event]
+	c.templates.mu.RUnlock()
+
+	if !ok {
+		return "", fmt.Errorf("no context template for event %q", event)
+	}
+
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to render event %q: %w", event, err)
+	}
+	return b.String(), nil
+}
+
+// SendEvent renders the template registered for an event type with data
+// and sends the result as a context message
+func (c *Client) SendEvent(event string, data interface{}) error {
+	message, err := c.RenderEvent(event, data)
+	if err != nil {
+		return err
+	}
+
+	c.templates.mu.RLock()
+	silent := c.templates.templates[event].silent
+	c.templates.mu.RUnlock()
+
+	return c.SendContext(message, silent)
+}
+
+// State Snapshots
+
+// StateSnapshot renders the current game state for Neuro
+type StateSnapshot interface {
+	RenderState() string
+}
+
+// StateSnapshotFunc adapts a function to the StateSnapshot interface
+type StateSnapshotFunc func() string
+
+// RenderState implements StateSnapshot
+func (f StateSnapshotFunc) RenderState() string {
+	return f()
+}
+
+// TruncationStrategy decides which part of an overlong state is kept
+type TruncationStrategy int
+
+const (
+	// TruncateEnd keeps the beginning of the state
+	TruncateEnd TruncationStrategy = iota
+	// TruncateStart keeps the end of the state
+	TruncateStart
+	// TruncateMiddle keeps the beginning and the end of the state
+	TruncateMiddle
+)
+
+// truncationMarker replaces the text removed by truncation
+const truncationMarker = "…"
+
+// StateOptions configures how state is attached to forces
+type StateOptions struct {
+	// MaxLength is the maximum state length in characters (0 means unlimited)
+	MaxLength int
+	// Truncation selects which part of an overlong state is kept
+	Truncation TruncationStrategy
+}
+
+// stateConfig holds the snapshot used for forces
+type stateConfig struct {
+	mu       sync.RWMutex
+	snapshot StateSnapshot
+	opts     StateOptions
+}
+
+// SetStateSnapshot sets the snapshot rendered as the state of every force that
+// does not pass WithState. States (explicit or rendered) longer than
+// opts.MaxLength are truncated. Pass a nil snapshot to stop attaching state.
+func (c *Client) SetStateSnapshot(snapshot StateSnapshot, opts StateOptions) {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+
+	c.state.snapshot = snapshot
+	c.state.opts = opts
+}
+
+// forceState returns the state to send with a force
+func (c *Client) forceState(explicit string) string {
+	c.state.mu.RLock()
+	snapshot, opts := c.state.snapshot, c.state.opts
+	c.state.mu.RUnlock()
+
+	state := explicit
+	if state == "" && snapshot != nil {
+		state = snapshot.RenderState()
+	}
+	return TruncateState(state, opts.MaxLength, opts.Truncation)
+}
+
+// TruncateState shortens state to at most maxLength characters using the given strategy.
+// A marker replaces the removed text. maxLength <= 0 disables truncation.
+func TruncateState(state string, maxLength int, strategy TruncationStrategy) string {
+	if maxLength <= 0 || utf8.RuneCountInString(state) <= maxLength {
+		return state
+	}
+
+	runes := []rune(state)
+	keep := maxLength - utf8.RuneCountInString(truncationMarker)
+	if keep <= 0 {
+		return string(runes[:maxLength])
+	}
+
+	switch strategy {
+	case TruncateStart:
+		return truncationMarker + string(runes[len(runes)-keep:])
+	case TruncateMiddle:
+		head := (keep + 1) / 2
+		tail := keep - head
+		return string(runes[:head]) + truncationMarker + string(runes[len(runes)-tail:])
+	default:
+		return string(runes[:keep]) + truncationMarker
+	}
+}
@@ -0,0 +1,127 @@
+package neuro
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateState(t *testing.T) {
+	tests := []struct {
+		name      string
+		state     string
+		maxLength int
+		strategy  TruncationStrategy
+		want      string
+	}{
+		{"unlimited", "abcdefghij", 0, TruncateEnd, "abcdefghij"},
+		{"negative is unlimited", "abcdefghij", -1, TruncateEnd, "abcdefghij"},
+		{"fits", "abcdefghij", 10, TruncateEnd, "abcdefghij"},
+		{"end", "abcdefghij", 5, TruncateEnd, "abcd…"},
+		{"start", "abcdefghij", 5, TruncateStart, "…ghij"},
+		{"middle even", "abcdefghij", 5, TruncateMiddle, "ab…ij"},
+		{"middle odd", "abcdefghij", 6, TruncateMiddle, "abc…ij"},
+		{"counts runes", "äöüßéèàç", 4, TruncateEnd, "äöü…"},
+		{"no room for the marker", "abcdefghij", 1, TruncateStart, "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TruncateState(tt.state, tt.maxLength, tt.strategy)
+			if got != tt.want {
+				t.Errorf("TruncateState() = %q, want %q", got, tt.want)
+			}
+			if tt.maxLength > 0 && utf8.RuneCountInString(got) > tt.maxLength {
+				t.Errorf("TruncateState() returned %d characters, limit is %d", utf8.RuneCountInString(got), tt.maxLength)
+			}
+		})
+	}
+}
+
+func TestSendEvent(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	if err := c.RegisterContextTemplate("bad", "{{.Name", false); err == nil {
+		t.Error("RegisterContextTemplate() accepted an invalid template")
+	}
+	if err := c.RegisterContextTemplate("damage", "{{.Target}} took {{.Amount}} damage", true); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		event   string
+		data    interface{}
+		want    string
+		wantErr bool
+	}{
+		{"struct", "damage", struct{ Target, Amount interface{} }{"Goblin", 3}, "Goblin took 3 damage", false},
+		{"map", "damage", map[string]interface{}{"Target": "Orc", "Amount": 5}, "Orc took 5 damage", false},
+		{"missing key", "damage", map[string]interface{}{"Target": "Orc"}, "", true},
+		{"unknown event", "heal", nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.SendEvent(tt.event, tt.data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("SendEvent() succeeded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			data := expectContext(t, conn)
+			if data.Message != tt.want || !data.Silent {
+				t.Errorf("context = %+v, want silent %q", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestForceState(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+	if err := c.RegisterAction(&testAction{name: "move"}); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+
+	board := strings.Repeat("x", 20)
+	c.SetStateSnapshot(StateSnapshotFunc(func() string { return board }), StateOptions{MaxLength: 8, Truncation: TruncateStart})
+
+	tests := []struct {
+		name string
+		opts []ForceOption
+		want string
+	}{
+		{"snapshot", nil, "…xxxxxxx"},
+		{"explicit state", []ForceOption{WithState("short")}, "short"},
+		{"explicit state truncated", []ForceOption{WithState("0123456789")}, "…3456789"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := c.ForceActions("Move", []string{"move"}, tt.opts...); err != nil {
+				t.Fatal(err)
+			}
+			if got := readForce(t, conn).State; got != tt.want {
+				t.Errorf("state = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Without a snapshot no state is attached
+	c.SetStateSnapshot(nil, StateOptions{})
+	if err := c.ForceActions("Move", []string{"move"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := readForce(t, conn).State; got != "" {
+		t.Errorf("state = %q without a snapshot", got)
+	}
+}
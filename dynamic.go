@@ -0,0 +1,113 @@
+package neuro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Dynamic Schemas
+
+// DynamicSchemaHandler is an ActionHandler whose description or schema depends on
+// game state, such as an enum of the cells that are still free.
+// The client re-reads GetDescription and GetSchema on RefreshActions and, when
+// ClientConfig.RefreshBeforeForce is set, before each force that names the action.
+type DynamicSchemaHandler interface {
+	ActionHandler
+	// HasDynamicSchema reports whether the definition should currently be refreshed
+	HasDynamicSchema() bool
+}
+
+// RefreshActions re-reads the definitions of the named actions and updates Neuro
+// for those that changed since they were last sent. Neuro ignores registering a
+// name twice, so each changed action is unregistered and then registered again.
+// With no names, every registered DynamicSchemaHandler is refreshed.
+func (c *Client) RefreshActions(names ...string) error {
+	return c.refreshActions(names, len(names) == 0)
+}
+
+// refreshActions refreshes the named actions (or all registered ones if names is empty).
+// If dynamicOnly is set, handlers that are not DynamicSchemaHandlers are skipped.
+func (c *Client) refreshActions(names []string, dynamicOnly bool) error {
+	c.actionsMu.Lock()
+	defer c.actionsMu.Unlock()
+
+	if len(names) == 0 {
+		names = sortedKeys(c.actions)
+	}
+
+	var changed []ActionDefinition
+	for _, name := range names {
+		h, ok := c.actions[name]
+		if !ok {
+			if dynamicOnly {
+				continue
+			}
+			return fmt.Errorf("action %q is not registered", name)
+		}
+		if dynamicOnly {
+			if d, ok := h.(DynamicSchemaHandler); !ok || !d.HasDynamicSchema() {
+				continue
+			}
+		}
+		if !isAvailable(h) {
+			continue
+		}
+
+		def := ActionDefinition{
+			Name:        name,
+			Description: h.GetDescription(),
+			Schema:      h.GetSchema(),
+		}
+		if bytes.Equal(c.sent[name], definitionSnapshot(def)) {
+			continue
+		}
+		changed = append(changed, def)
+	}
+
+	if len(changed) == 0 {
+		return nil
+	}
+
+	// Only unregister what Neuro currently knows about, keeping the definitions
+	// it had so they can be restored
+	var stale []string
+	var previous []ActionDefinition
+	for _, def := range changed {
+		if sent, ok := c.sent[def.Name]; ok {
+			stale = append(stale, def.Name)
+			var old ActionDefinition
+			if err := json.Unmarshal(sent, &old); err == nil {
+				previous = append(previous, old)
+			}
+		}
+	}
+
+	c.logger.Info("Refreshing changed actions", "count", len(changed))
+
+	if len(stale) > 0 {
+		if err := c.sendUnregister(stale); err != nil {
+			return err
+		}
+	}
+	if err := c.sendRegister(changed); err != nil {
+		// Put the previous definitions back so Neuro keeps the actions
+		if len(previous) > 0 {
+			if rerr := c.sendRegister(previous); rerr != nil {
+				c.logger.Error("Failed to restore refreshed actions", "error", rerr)
+			}
+		}
+		return fmt.Errorf("failed to refresh actions: %w", err)
+	}
+	return nil
+}
+
+// definitionSnapshot captures a definition as canonical JSON so later changes can be detected
+// even if the handler mutates and returns the same schema maps
+func definitionSnapshot(def ActionDefinition) []byte {
+	b, err := json.Marshal(def)
+	if err != nil {
+		return nil
+	}
+	return b
+}
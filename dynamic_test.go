@@ -0,0 +1,128 @@
+package neuro
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// dynamicAction offers the cells in its schema, which the test can change
+type dynamicAction struct {
+	testAction
+
+	mu    sync.Mutex
+	cells []interface{}
+}
+
+func (a *dynamicAction) HasDynamicSchema() bool { return true }
+
+func (a *dynamicAction) GetSchema() *ActionSchema {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return &ActionSchema{
+		Type: "object",
+		Properties: map[string]interface{}{
+			"cell": map[string]interface{}{"enum": append([]interface{}(nil), a.cells...)},
+		},
+		Required: []string{"cell"},
+	}
+}
+
+func (a *dynamicAction) setCells(cells ...interface{}) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.cells = cells
+}
+
+// registeredCells reads the next message as a registration of a dynamicAction and returns its cells
+func registeredCells(t *testing.T, conn *testConn) []interface{} {
+	t.Helper()
+
+	var data RegisterActionsData
+	if err := json.Unmarshal(conn.expect(CommandRegisterActions).Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Actions) != 1 || data.Actions[0].Schema == nil {
+		t.Fatalf("registered %+v, want one action with a schema", data.Actions)
+	}
+	cell, _ := data.Actions[0].Schema.Properties["cell"].(map[string]interface{})
+	cells, _ := cell["enum"].([]interface{})
+	return cells
+}
+
+func TestRefreshActions(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	a := &dynamicAction{testAction: testAction{name: "place"}}
+	a.setCells("a1", "b2")
+	if err := c.RegisterAction(a); err != nil {
+		t.Fatal(err)
+	}
+	registeredCells(t, conn)
+
+	// A changed schema is unregistered and then registered again
+	a.setCells("b2")
+	if err := c.RefreshActions(); err != nil {
+		t.Fatal(err)
+	}
+	var data UnregisterActionsData
+	if err := json.Unmarshal(conn.expect(CommandUnregisterActions).Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(data.ActionNames, []string{"place"}) {
+		t.Errorf("unregistered %v, want [place]", data.ActionNames)
+	}
+	if cells := registeredCells(t, conn); !reflect.DeepEqual(cells, []interface{}{"b2"}) {
+		t.Errorf("registered cells %v, want [b2]", cells)
+	}
+
+	// An unchanged schema sends nothing, so the next message is the context
+	if err := c.RefreshActions("place"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SendContext("unchanged", true); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandContext)
+
+	if err := c.RefreshActions("missing"); err == nil {
+		t.Error("refreshing an unregistered action succeeded")
+	}
+}
+
+func TestRefreshActionsRestoresOnFailure(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	a := &dynamicAction{testAction: testAction{name: "place"}}
+	a.setCells("a1", "b2")
+	if err := c.RegisterAction(a); err != nil {
+		t.Fatal(err)
+	}
+	registeredCells(t, conn)
+
+	// An infinite value cannot be marshalled, so the new definition is never sent
+	a.setCells(math.Inf(1))
+	if err := c.RefreshActions(); err == nil {
+		t.Fatal("RefreshActions() succeeded with a schema that cannot be sent")
+	}
+	conn.expect(CommandUnregisterActions)
+	if cells := registeredCells(t, conn); !reflect.DeepEqual(cells, []interface{}{"a1", "b2"}) {
+		t.Errorf("restored cells %v, want [a1 b2]", cells)
+	}
+
+	// Neuro has the previous definition again, so fixing the schema refreshes it
+	a.setCells("a1")
+	if err := c.RefreshActions(); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandUnregisterActions)
+	if cells := registeredCells(t, conn); !reflect.DeepEqual(cells, []interface{}{"a1"}) {
+		t.Errorf("registered cells %v, want [a1]", cells)
+	}
+}
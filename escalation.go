@@ -0,0 +1,112 @@
+package neuro
+
+import (
+	"time"
+)
+
+// Priority Escalation
+
+// EscalationStep re-issues an unanswered force once After has passed since it was first sent
+type EscalationStep struct {
+	After    time.Duration
+	Priority Priority
+	// Query replaces the force query from this step on (optional)
+	Query string
+}
+
+// EscalationPolicy raises the priority of a force until Neuro answers it
+type EscalationPolicy struct {
+	// Steps are applied in order and should have increasing After values
+	Steps []EscalationStep
+	// AllowCritical permits steps with PriorityCritical. Without it they are capped at PriorityHigh.
+	AllowCritical bool
+}
+
+// DefaultEscalationPolicy raises a force to medium priority after 30 seconds
+// and to high priority after 60 seconds
+func DefaultEscalationPolicy() EscalationPolicy {
+	return EscalationPolicy{
+		Steps: []EscalationStep{
+			{After: 30 * time.Second, Priority: PriorityMedium},
+			{After: 60 * time.Second, Priority: PriorityHigh},
+		},
+	}
+}
+
+// WithEscalation re-issues the force with the policy's priorities while it stays unanswered.
+// A force stops escalating once an action answering it succeeds or it is superseded;
+// answers that fail validation do not stop it.
+func WithEscalation(policy EscalationPolicy) ForceOption {
+	return func(c *forceConfig) {
+		c.escalation = &escalation{policy: policy}
+	}
+}
+
+// escalation tracks a force's progress through its policy
+type escalation struct {
+	policy EscalationPolicy
+	start  time.Time
+	next   int
+}
+
+// scheduleEscalation arms the next escalation step of a force that was just sent
+func (c *Client) scheduleEscalation(force *activeForce, actionNames []string, config *forceConfig) {
+	e := config.escalation
+	if e.start.IsZero() {
+		e.start = force.at
+	}
+	if e.next >= len(e.policy.Steps) {
+		return
+	}
+
+	delay := time.Until(e.start.Add(e.policy.Steps[e.next].After))
+	time.AfterFunc(delay, func() {
+		c.escalate(force, actionNames, config)
+	})
+}
+
+// escalate re-issues a force with the next priority of its policy if it is still open.
+// completeForce clears lastForce on a successful answer and a newer force replaces it;
+// recordForce checks this again when the escalated force is recorded.
+func (c *Client) escalate(force *activeForce, actionNames []string, config *forceConfig) {
+	c.forceMu.Lock()
+	active := c.lastForce == force
+	c.forceMu.Unlock()
+	if !active {
+		return
+	}
+
+	e := *config.escalation
+	step := e.policy.Steps[e.next]
+	e.next++
+
+	next := *config
+	next.escalation = &e
+	// recordForce hands the waiter over only if the force is still unanswered then
+	next.waiter = nil
+	next.escalates = force
+	next.priority = step.Priority
+	if next.priority == PriorityCritical && !e.policy.AllowCritical {
+		c.logger.Warn("Capping force escalation at high priority; set AllowCritical to escalate to critical", "query", force.query)
+		next.priority = PriorityHigh
+	}
+
+	query := force.query
+	if step.Query != "" {
+		query = step.Query
+	}
+
+	c.logger.Info("Escalating unanswered force",
+		"query", query,
+		"actions", actionNames,
+		"from", force.priority,
+		"to", next.priority,
+		"waited", time.Since(e.start).Round(time.Millisecond),
+	)
+	c.metrics.ForceEscalated(next.priority)
+
+	// A failed send also fails the waiter that was handed over
+	if err := c.sendForce(query, actionNames, &next); err != nil {
+		c.logger.Error("Failed to escalate force", "query", query, "error", err)
+	}
+}
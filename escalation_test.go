@@ -0,0 +1,225 @@
+package neuro
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// pickyAction only accepts {"ok": true}
+type pickyAction struct{}
+
+func (pickyAction) GetName() string          { return "pick" }
+func (pickyAction) GetDescription() string   { return "Pick something" }
+func (pickyAction) GetSchema() *ActionSchema { return nil }
+func (pickyAction) Execute(interface{})      {}
+
+func (pickyAction) Validate(data json.RawMessage) (interface{}, ExecutionResult) {
+	var params struct{ OK bool }
+	if err := ParseActionData(data, &params); err != nil || !params.OK {
+		return nil, NewFailureResult("Not ok")
+	}
+	return nil, NewSuccessResult("")
+}
+
+// readForce reads the next message as a force
+func readForce(t *testing.T, conn *testConn) ForceActionsData {
+	t.Helper()
+
+	var data ForceActionsData
+	if err := json.Unmarshal(conn.expect(CommandForceActions).Data, &data); err != nil {
+		t.Fatalf("invalid force: %v", err)
+	}
+	return data
+}
+
+func TestEscalation(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  EscalationPolicy
+		answers []string // sent right after the first force, all rejected
+		want    []Priority
+		queries []string
+	}{
+		{
+			name: "unanswered",
+			policy: EscalationPolicy{Steps: []EscalationStep{
+				{After: 20 * time.Millisecond, Priority: PriorityMedium},
+				{After: 40 * time.Millisecond, Priority: PriorityHigh, Query: "Hurry up"},
+			}},
+			want:    []Priority{PriorityLow, PriorityMedium, PriorityHigh},
+			queries: []string{"Pick", "Pick", "Hurry up"},
+		},
+		{
+			name: "failed answers keep escalating",
+			policy: EscalationPolicy{Steps: []EscalationStep{
+				{After: 20 * time.Millisecond, Priority: PriorityMedium},
+			}},
+			answers: []string{`{"ok": false}`, `{}`},
+			want:    []Priority{PriorityLow, PriorityMedium},
+			queries: []string{"Pick", "Pick"},
+		},
+		{
+			name: "critical is capped",
+			policy: EscalationPolicy{Steps: []EscalationStep{
+				{After: 20 * time.Millisecond, Priority: PriorityCritical},
+			}},
+			want:    []Priority{PriorityLow, PriorityHigh},
+			queries: []string{"Pick", "Pick"},
+		},
+		{
+			name: "critical allowed",
+			policy: EscalationPolicy{Steps: []EscalationStep{
+				{After: 20 * time.Millisecond, Priority: PriorityCritical},
+			}, AllowCritical: true},
+			want:    []Priority{PriorityLow, PriorityCritical},
+			queries: []string{"Pick", "Pick"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			c := newTestClient(t, s, nil)
+			conn := connect(t, c, s)
+
+			if err := c.RegisterAction(pickyAction{}); err != nil {
+				t.Fatal(err)
+			}
+			conn.expect(CommandRegisterActions)
+
+			if err := c.ForceActions("Pick", []string{"pick"}, WithEscalation(tt.policy)); err != nil {
+				t.Fatal(err)
+			}
+			for i, want := range tt.want {
+				force := readForce(t, conn)
+				if force.Priority != want || force.Query != tt.queries[i] {
+					t.Fatalf("force %d = %q at %s, want %q at %s", i, force.Query, force.Priority, tt.queries[i], want)
+				}
+				if i == 0 {
+					for j, answer := range tt.answers {
+						conn.sendAction(string(rune('a'+j)), "pick", answer)
+						if result := conn.expectResult(); result.Success {
+							t.Fatalf("answer %s got %+v, want failure", answer, result)
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEscalationStopsWhenAnswered(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	if err := c.RegisterAction(pickyAction{}); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+
+	policy := EscalationPolicy{Steps: []EscalationStep{{After: 30 * time.Millisecond, Priority: PriorityHigh}}}
+	if err := c.ForceActions("Pick", []string{"pick"}, WithEscalation(policy)); err != nil {
+		t.Fatal(err)
+	}
+	readForce(t, conn)
+
+	conn.sendAction("1", "pick", `{"ok": true}`)
+	if result := conn.expectResult(); !result.Success {
+		t.Fatalf("answer got %+v, want success", result)
+	}
+
+	// Past the step's deadline, the next message is the marker rather than an escalated force
+	time.Sleep(60 * time.Millisecond)
+	if err := c.SendContext("marker", true); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandContext)
+}
+
+func TestEscalationAnsweredAtDeadline(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	if err := c.RegisterAction(pickyAction{}); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+
+	const step = 20 * time.Millisecond
+	policy := EscalationPolicy{Steps: []EscalationStep{{After: step, Priority: PriorityHigh}}}
+	for i := 0; i < 20; i++ {
+		done := make(chan promptResult, 1)
+		go func() {
+			invocation, err := c.ForceAndWait(context.Background(), "Pick", []string{"pick"}, WithEscalation(policy))
+			done <- promptResult{invocation, err}
+		}()
+
+		// The answer races the escalation, which may or may not have been sent
+		time.Sleep(step)
+		id := fmt.Sprint(i)
+		conn.sendAction(id, "pick", `{"ok": true}`)
+		for {
+			msg := conn.read()
+			if msg.Command == CommandForceActions {
+				continue
+			}
+			var result ActionResultData
+			if err := json.Unmarshal(msg.Data, &result); err != nil || msg.Command != CommandActionResult || result.ID != id || !result.Success {
+				t.Fatalf("got %s %s, want a successful result for %s", msg.Command, msg.Data, id)
+			}
+			break
+		}
+
+		select {
+		case got := <-done:
+			if got.err != nil || got.value.(*ActionInvocation) == nil {
+				t.Fatalf("ForceAndWait() = %v, %v, want the answer", got.value, got.err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("ForceAndWait lost the answer")
+		}
+		waitFor(t, "force to be answered", func() bool { return c.ActiveForce() == nil })
+	}
+}
+
+func TestEscalationKeepsQueueDeadline(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, func(config *ClientConfig) {
+		config.QueueForces = true
+		config.QueueTimeout = 100 * time.Millisecond
+	})
+	conn := connect(t, c, s)
+
+	if err := c.RegisterAction(pickyAction{}); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+
+	policy := EscalationPolicy{Steps: []EscalationStep{
+		{After: 40 * time.Millisecond, Priority: PriorityMedium},
+		{After: 80 * time.Millisecond, Priority: PriorityHigh},
+	}}
+	start := time.Now()
+	if err := c.ForceActions("Pick", []string{"pick"}, WithEscalation(policy)); err != nil {
+		t.Fatal(err)
+	}
+	readForce(t, conn)
+	if _, err := c.QueueForce("Next", []string{"pick"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Escalating does not push back when the queued force is sent
+	for {
+		if force := readForce(t, conn); force.Query == "Next" {
+			break
+		}
+	}
+	if waited := time.Since(start); waited > 180*time.Millisecond {
+		t.Errorf("queued force was sent after %v, want about the queue timeout", waited)
+	}
+}
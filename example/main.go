@@ -193,6 +193,12 @@ func (a *PlayAction) GetSchema() *neuro.ActionSchema {
 	}, []string{"cell"})
 }
 
+// HasDynamicSchema marks the schema as dynamic: the enum of free cells
+// changes after every move, so the client refreshes it before each force
+func (a *PlayAction) HasDynamicSchema() bool {
+	return true
+}
+
 func (a *PlayAction) Validate(data json.RawMessage) (interface{}, neuro.ExecutionResult) {
 	var params struct {
 		Cell string `json:"cell"`
@@ -227,8 +233,9 @@ func main() {
 
 	// Create client
 	client, err := neuro.NewClient(neuro.ClientConfig{
-		Game:         "Example Game",
-		WebsocketURL: wsURL,
+		Game:               "Example Game",
+		WebsocketURL:       wsURL,
+		RefreshBeforeForce: true,
 	})
 	if err != nil {
 		log.Fatalf("Failed to create client: %v", err)
@@ -0,0 +1,234 @@
+package neuro
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Action Export
+
+// ErrGoldenMismatch is returned by CheckActionsGolden when the exported actions
+// differ from the golden file
+var ErrGoldenMismatch = errors.New("actions differ from golden file")
+
+// ExportActions returns the definitions of handlers as canonical JSON: sorted by
+// name, with sorted keys and indented, so it can be committed and diffed.
+// Availability is ignored, every handler is exported.
+func ExportActions(handlers []ActionHandler) ([]byte, error) {
+	defs := make([]ActionDefinition, len(handlers))
+	for i, h := range handlers {
+		defs[i] = ActionDefinition{
+			Name:        h.GetName(),
+			Description: h.GetDescription(),
+			Schema:      h.GetSchema(),
+		}
+	}
+	return ExportDefinitions(defs)
+}
+
+// ExportDefinitions returns action definitions as canonical JSON. See ExportActions.
+func ExportDefinitions(defs []ActionDefinition) ([]byte, error) {
+	sorted := append([]ActionDefinition(nil), defs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Name == sorted[i-1].Name {
+			return nil, fmt.Errorf("action %q is defined more than once", sorted[i].Name)
+		}
+	}
+
+	// Round-trip through a generic value so schema maps and structs are encoded the
+	// same way, with every object's keys sorted
+	b, err := json.Marshal(sorted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal actions: %w", err)
+	}
+	var generic interface{}
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return nil, fmt.Errorf("failed to marshal actions: %w", err)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(generic); err != nil {
+		return nil, fmt.Errorf("failed to marshal actions: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ExportActions returns the actions registered or declared with the client and
+// those of its modules as canonical JSON. The client does not need to be connected,
+// so a game can declare its actions with DeclareActions, add its modules and
+// export them in a test or build step.
+func (c *Client) ExportActions() ([]byte, error) {
+	c.actionsMu.RLock()
+	handlers := make(map[string]ActionHandler, len(c.actions))
+	for name, h := range c.actions {
+		handlers[name] = h
+	}
+	c.actionsMu.RUnlock()
+
+	for _, m := range c.moduleList() {
+		for _, h := range m.Actions() {
+			handlers[h.GetName()] = h
+		}
+	}
+
+	list := make([]ActionHandler, 0, len(handlers))
+	for _, name := range sortedKeys(handlers) {
+		list = append(list, handlers[name])
+	}
+	return ExportActions(list)
+}
+
+// CheckActionsGolden compares exported actions with a golden file. If they differ,
+// it returns an error wrapping ErrGoldenMismatch that contains the diff. With update
+// set, the golden file is written instead.
+func CheckActionsGolden(path string, current []byte, update bool) error {
+	if update {
+		if err := os.WriteFile(path, current, 0o644); err != nil {
+			return fmt.Errorf("failed to write golden file: %w", err)
+		}
+		return nil
+	}
+
+	golden, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read golden file: %w", err)
+	}
+	if diff := DiffActions(golden, current); diff != "" {
+		return fmt.Errorf("%w %s:\n%s", ErrGoldenMismatch, path, strings.TrimSuffix(diff, "\n"))
+	}
+	return nil
+}
+
+// DiffActions returns a unified diff of two exports, or "" if they are equal
+func DiffActions(golden, current []byte) string {
+	if bytes.Equal(golden, current) {
+		return ""
+	}
+	return unifiedDiff("golden", "current", splitLines(string(golden)), splitLines(string(current)), 3)
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// diffOp is one line of a diff: ' ' kept, '-' removed or '+' added
+type diffOp struct {
+	kind byte
+	line string
+	a, b int // line indexes in the old and new text before this line
+}
+
+// unifiedDiff diffs two line lists by longest common subsequence, which is fast
+// enough for action exports, and prints hunks with the given lines of context
+func unifiedDiff(oldName, newName string, a, b []string, context int) string {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i], i, j})
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, diffOp{'+', b[j], i, j})
+			j++
+		default:
+			ops = append(ops, diffOp{'-', a[i], i, j})
+			i++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+
+	for start := 0; start < len(ops); {
+		// Find the next change and the end of its hunk, merging changes whose
+		// context would overlap
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		last := first
+		for k := first; k < len(ops); k++ {
+			if ops[k].kind != ' ' {
+				last = k
+			} else if k-last > 2*context {
+				break
+			}
+		}
+
+		from := first - context
+		if from < start {
+			from = start
+		}
+		to := last + context + 1
+		if to > len(ops) {
+			to = len(ops)
+		}
+
+		var oldCount, newCount int
+		for _, op := range ops[from:to] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(ops[from].a, oldCount), hunkRange(ops[from].b, newCount))
+		for _, op := range ops[from:to] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			out.WriteByte('\n')
+		}
+
+		start = to
+	}
+
+	return out.String()
+}
+
+// hunkRange formats a hunk's line range the way unified diffs do
+func hunkRange(index, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", index)
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", index+1)
+	}
+	return fmt.Sprintf("%d,%d", index+1, count)
+}
@@ -0,0 +1,250 @@
+package neuro
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     string
+	}{
+		{"changed line", "a\nb\nc\n", "a\nB\nc\n", `
+@@ -1,3 +1,3 @@
+ a
+-b
++B
+ c
+`},
+		{"separate hunks", "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n", "1\nx\n3\n4\n5\n6\n7\n8\n9\n10\n11\ny\n", `
+@@ -1,5 +1,5 @@
+ 1
+-2
++x
+ 3
+ 4
+ 5
+@@ -9,4 +9,4 @@
+ 9
+ 10
+ 11
+-12
++y
+`},
+		{"overlapping context merges hunks", "1\n2\n3\n4\n5\n6\n7\n8\n", "1\nx\n3\n4\n5\n6\n7\ny\n", `
+@@ -1,8 +1,8 @@
+ 1
+-2
++x
+ 3
+ 4
+ 5
+ 6
+ 7
+-8
++y
+`},
+		{"added to empty", "", "a\nb\n", `
+@@ -0,0 +1,2 @@
++a
++b
+`},
+		{"removed everything", "a\nb\n", "", `
+@@ -1,2 +0,0 @@
+-a
+-b
+`},
+		{"inserted line", "a\nb\nc\nd\ne\nf\ng\n", "a\nb\nc\nd\nnew\ne\nf\ng\n", `
+@@ -2,6 +2,7 @@
+ b
+ c
+ d
++new
+ e
+ f
+ g
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := unifiedDiff("golden", "current", splitLines(tt.old), splitLines(tt.new), 3)
+			want := "--- golden\n+++ current" + tt.want
+			if got != want {
+				t.Errorf("unifiedDiff() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestDiffActionsEqual(t *testing.T) {
+	export := []byte("[\n  {\n    \"name\": \"jump\"\n  }\n]\n")
+	if diff := DiffActions(export, export); diff != "" {
+		t.Errorf("DiffActions() of equal exports = %q, want none", diff)
+	}
+}
+
+func TestExportActions(t *testing.T) {
+	handlers := []ActionHandler{
+		&testAction{name: "jump"},
+		&testAction{name: "duck"},
+	}
+
+	export, err := ExportActions(handlers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[
+  {
+    "description": "Test action duck",
+    "name": "duck"
+  },
+  {
+    "description": "Test action jump",
+    "name": "jump"
+  }
+]
+`
+	if string(export) != want {
+		t.Errorf("ExportActions() =\n%s\nwant\n%s", export, want)
+	}
+
+	// The order handlers are passed in does not change the export
+	reversed, err := ExportActions([]ActionHandler{handlers[1], handlers[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(reversed) != string(export) {
+		t.Errorf("export depends on handler order:\n%s", DiffActions(export, reversed))
+	}
+
+	if _, err := ExportActions([]ActionHandler{&testAction{name: "jump"}, &testAction{name: "jump"}}); err == nil {
+		t.Error("ExportActions() accepted a duplicate action")
+	}
+}
+
+func TestExportDefinitionsSortsSchemaKeys(t *testing.T) {
+	def := ActionDefinition{
+		Name:        "buy",
+		Description: "Buy an item",
+		Schema: &ActionSchema{
+			Type: "object",
+			Properties: map[string]interface{}{
+				"quantity": map[string]interface{}{"type": "integer", "minimum": 1},
+				"item":     map[string]interface{}{"type": "string", "enum": []string{"sword", "<shield>"}},
+			},
+			Required: []string{"item"},
+		},
+	}
+
+	export, err := ExportDefinitions([]ActionDefinition{def})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(export)
+	for _, want := range []string{
+		`"item": {`,
+		`"enum": [`,
+		`"<shield>"`, // HTML characters are not escaped
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("export does not contain %s:\n%s", want, got)
+		}
+	}
+	if strings.Index(got, `"item"`) > strings.Index(got, `"quantity"`) {
+		t.Errorf("properties are not sorted:\n%s", got)
+	}
+	if strings.Index(got, `"minimum"`) > strings.Index(got, `"type": "integer"`) {
+		t.Errorf("property keys are not sorted:\n%s", got)
+	}
+}
+
+func TestCheckActionsGolden(t *testing.T) {
+	golden := []byte("[\n  {\n    \"name\": \"jump\"\n  }\n]\n")
+	changed := []byte("[\n  {\n    \"name\": \"duck\"\n  }\n]\n")
+
+	tests := []struct {
+		name     string
+		golden   []byte // nil means no golden file
+		current  []byte
+		update   bool
+		wantErr  error
+		failing  bool
+		wantFile []byte
+	}{
+		{"matches", golden, golden, false, nil, false, golden},
+		{"differs", golden, changed, false, ErrGoldenMismatch, true, golden},
+		{"update rewrites", golden, changed, true, nil, false, changed},
+		{"update creates", nil, changed, true, nil, false, changed},
+		{"missing golden file", nil, changed, false, nil, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "actions.json")
+			if tt.golden != nil {
+				if err := os.WriteFile(path, tt.golden, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := CheckActionsGolden(path, tt.current, tt.update)
+			if tt.failing != (err != nil) {
+				t.Fatalf("CheckActionsGolden() = %v, want failing %v", err, tt.failing)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("CheckActionsGolden() = %v, want %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrGoldenMismatch) && !strings.Contains(err.Error(), `-    "name": "jump"`) {
+				t.Errorf("mismatch error does not contain the diff:\n%v", err)
+			}
+
+			file, _ := os.ReadFile(path)
+			if string(file) != string(tt.wantFile) {
+				t.Errorf("golden file = %q, want %q", file, tt.wantFile)
+			}
+		})
+	}
+}
+
+func TestDeclareActions(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+
+	if err := c.RegisterAction(&testAction{name: "jump"}); err == nil {
+		t.Fatal("RegisterAction succeeded without a connection")
+	}
+	if err := c.DeclareActions([]ActionHandler{&testAction{name: ""}}); err == nil {
+		t.Fatal("DeclareActions accepted an empty name")
+	}
+	if err := c.DeclareActions([]ActionHandler{&testAction{name: "jump"}, &testAction{name: "duck"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	export, err := c.ExportActions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var defs []ActionDefinition
+	if err := json.Unmarshal(export, &defs); err != nil {
+		t.Fatal(err)
+	}
+	if len(defs) != 2 || defs[0].Name != "duck" || defs[1].Name != "jump" {
+		t.Fatalf("ExportActions() = %s, want duck and jump", export)
+	}
+
+	conn := connect(t, c, s)
+	var data RegisterActionsData
+	if err := json.Unmarshal(conn.expect(CommandRegisterActions).Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Actions) != 2 {
+		t.Fatalf("registered %d actions on connect, want 2", len(data.Actions))
+	}
+}
@@ -0,0 +1,292 @@
+package neuro
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Force Queue
+
+var (
+	// ErrForceCancelled is returned by ForceAndWait when its queued force is cancelled
+	ErrForceCancelled = errors.New("force cancelled before it was sent")
+	// ErrForceStale is returned by ForceAndWait when all actions of its force are unregistered
+	ErrForceStale = errors.New("force actions were unregistered")
+	// ErrForceTimedOut is returned by ForceAndWait when its force held back queued
+	// forces for longer than the queue timeout without being answered
+	ErrForceTimedOut = errors.New("force was not answered in time")
+)
+
+// DefaultQueueTimeout is how long an unanswered force may hold back queued forces
+const DefaultQueueTimeout = 60 * time.Second
+
+// priorityRank orders priorities from lowest to highest
+func priorityRank(p Priority) int {
+	switch p {
+	case PriorityMedium:
+		return 1
+	case PriorityHigh:
+		return 2
+	case PriorityCritical:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// QueuedForce is a force waiting for the active force to be answered.
+// Its exported fields are read-only.
+type QueuedForce struct {
+	Query       string
+	ActionNames []string
+	Priority    Priority
+	QueuedAt    time.Time
+
+	client *Client
+	config *forceConfig
+	seq    uint64
+	done   chan struct{}
+	err    error
+}
+
+// Cancel removes the force from the queue. It reports false if the force
+// was already sent or dropped.
+func (q *QueuedForce) Cancel() bool {
+	fq := &q.client.forceQueue
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+
+	for i, item := range fq.items {
+		if item == q {
+			fq.items = append(fq.items[:i], fq.items[i+1:]...)
+			q.drop(ErrForceCancelled)
+			return true
+		}
+	}
+	return false
+}
+
+// Done is closed once the force has been sent or dropped
+func (q *QueuedForce) Done() <-chan struct{} {
+	return q.done
+}
+
+// Err returns why the force was not sent (ErrForceCancelled, ErrForceStale or a
+// send error), or nil if it was sent. It is only meaningful once Done is closed.
+func (q *QueuedForce) Err() error {
+	<-q.done
+	return q.err
+}
+
+// drop ends a force that will never be sent. Must be called with the queue lock held.
+func (q *QueuedForce) drop(err error) {
+	q.err = err
+	if q.config.waiter != nil {
+		q.config.waiter.finish(nil, err)
+	}
+	close(q.done)
+}
+
+// forceQueue holds forces waiting to be sent, one at a time
+type forceQueue struct {
+	mu    sync.Mutex
+	items []*QueuedForce
+	seq   uint64
+}
+
+// QueueForce sends a force once no other force is waiting for an answer.
+// Queued forces are sent highest priority first, then in the order they were
+// queued. Actions that are no longer registered when the force is sent are left
+// out, and a force with none left is dropped with ErrForceStale.
+func (c *Client) QueueForce(query string, actionNames []string, opts ...ForceOption) (*QueuedForce, error) {
+	if len(actionNames) == 0 {
+		return nil, errors.New("must specify at least one action name")
+	}
+
+	config := newForceConfig(opts)
+
+	c.forceQueue.mu.Lock()
+	c.forceQueue.seq++
+	q := &QueuedForce{
+		Query:       query,
+		ActionNames: append([]string(nil), actionNames...),
+		Priority:    config.priority,
+		QueuedAt:    time.Now(),
+		client:      c,
+		config:      config,
+		seq:         c.forceQueue.seq,
+		done:        make(chan struct{}),
+	}
+	c.forceQueue.items = append(c.forceQueue.items, q)
+	c.metrics.QueueDepth("forces", len(c.forceQueue.items))
+	c.forceQueue.mu.Unlock()
+
+	c.dispatchForces()
+	return q, nil
+}
+
+// QueuedForces returns the forces waiting to be sent, in the order they will be sent
+func (c *Client) QueuedForces() []*QueuedForce {
+	c.forceQueue.mu.Lock()
+	defer c.forceQueue.mu.Unlock()
+
+	c.forceQueue.sort()
+	return append([]*QueuedForce(nil), c.forceQueue.items...)
+}
+
+// ActiveForce returns the force currently waiting for an answer, or nil
+func (c *Client) ActiveForce() *ForceRecord {
+	c.forceMu.Lock()
+	defer c.forceMu.Unlock()
+
+	f := c.lastForce
+	if f == nil {
+		return nil
+	}
+	return &ForceRecord{
+		Query:       f.query,
+		ActionNames: append([]string(nil), f.actionNames...),
+		Priority:    f.priority,
+		ForcedAt:    f.at,
+	}
+}
+
+// sort orders the queue by priority, then by queue order. Must be called with q.mu held.
+func (q *forceQueue) sort() {
+	sort.SliceStable(q.items, func(i, j int) bool {
+		ri, rj := priorityRank(q.items[i].Priority), priorityRank(q.items[j].Priority)
+		if ri != rj {
+			return ri > rj
+		}
+		return q.items[i].seq < q.items[j].seq
+	})
+}
+
+// dispatchForces sends the next queued force if no force is active
+func (c *Client) dispatchForces() {
+	fq := &c.forceQueue
+	fq.mu.Lock()
+	defer fq.mu.Unlock()
+
+	for len(fq.items) > 0 {
+		c.forceMu.Lock()
+		active := c.lastForce
+		if active != nil {
+			c.armQueueTimeout(active)
+		}
+		c.forceMu.Unlock()
+		if active != nil {
+			return
+		}
+
+		fq.sort()
+		next := fq.items[0]
+		fq.items = fq.items[1:]
+		c.metrics.QueueDepth("forces", len(fq.items))
+
+		names := c.registeredNames(next.ActionNames)
+		if len(names) == 0 {
+			c.logger.Info("Dropping queued force with no registered actions", "query", next.Query, "actions", next.ActionNames)
+			next.drop(ErrForceStale)
+			continue
+		}
+
+		if err := c.sendForce(next.Query, names, next.config); err != nil {
+			c.logger.Error("Failed to send queued force", "query", next.Query, "error", err)
+			next.drop(err)
+			continue
+		}
+		close(next.done)
+	}
+}
+
+// queueTimeout returns the configured queue timeout (zero disables it)
+func (c *Client) queueTimeout() time.Duration {
+	switch {
+	case c.config.QueueTimeout < 0:
+		return 0
+	case c.config.QueueTimeout == 0:
+		return DefaultQueueTimeout
+	default:
+		return c.config.QueueTimeout
+	}
+}
+
+// armQueueTimeout gives the active force a deadline once forces are queued behind it.
+// Must be called with forceMu held.
+func (c *Client) armQueueTimeout(force *activeForce) {
+	timeout := c.queueTimeout()
+	if timeout == 0 || force.queueTimer != nil {
+		return
+	}
+	force.queueTimer = time.AfterFunc(time.Until(force.at.Add(timeout)), func() {
+		c.expireForce(force)
+	})
+}
+
+// expireForce stops tracking a force that stayed unanswered past the queue
+// timeout, so the next queued force can be sent
+func (c *Client) expireForce(force *activeForce) {
+	c.forceMu.Lock()
+	if c.lastForce != force {
+		c.forceMu.Unlock()
+		return
+	}
+	c.lastForce = nil
+	if force.waiter != nil {
+		force.waiter.finish(nil, ErrForceTimedOut)
+		force.waiter = nil
+	}
+	c.forceMu.Unlock()
+
+	c.logger.Warn("Giving up on unanswered force", "query", force.query, "waited", time.Since(force.at).Round(time.Millisecond))
+	force.span.SetStatus(false, "timed out")
+	force.span.End()
+
+	c.dispatchForces()
+}
+
+// registeredNames keeps the names that have a registered, available handler
+func (c *Client) registeredNames(names []string) []string {
+	c.actionsMu.RLock()
+	defer c.actionsMu.RUnlock()
+
+	var kept []string
+	for _, name := range names {
+		if h, ok := c.actions[name]; ok && isAvailable(h) {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
+
+// supersedeStaleForce stops tracking the active force once none of its
+// actions are registered, so the next queued force can be sent
+func (c *Client) supersedeStaleForce() {
+	c.forceMu.Lock()
+	force := c.lastForce
+	c.forceMu.Unlock()
+
+	if force == nil || len(c.registeredNames(force.actionNames)) > 0 {
+		return
+	}
+
+	c.forceMu.Lock()
+	if c.lastForce != force {
+		c.forceMu.Unlock()
+		return
+	}
+	c.lastForce = nil
+	if force.waiter != nil {
+		force.waiter.finish(nil, ErrForceStale)
+		force.waiter = nil
+	}
+	c.forceMu.Unlock()
+
+	force.span.SetStatus(false, "actions unregistered")
+	force.span.End()
+
+	c.dispatchForces()
+}
@@ -0,0 +1,211 @@
+package neuro
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestForceQueueOrder(t *testing.T) {
+	type queued struct {
+		query    string
+		priority Priority
+	}
+
+	tests := []struct {
+		name   string
+		queued []queued
+		want   string
+	}{
+		{"same priority keeps queue order", []queued{
+			{"a", PriorityLow}, {"b", PriorityLow}, {"c", PriorityLow},
+		}, "a b c"},
+		{"higher priority first", []queued{
+			{"a", PriorityLow}, {"b", PriorityMedium}, {"c", PriorityHigh}, {"d", PriorityCritical},
+		}, "d c b a"},
+		{"queue order within a priority", []queued{
+			{"a", PriorityLow}, {"b", PriorityHigh}, {"c", PriorityLow}, {"d", PriorityHigh},
+		}, "b d a c"},
+		{"empty priority ranks as low", []queued{
+			{"a", ""}, {"b", PriorityLow}, {"c", PriorityMedium},
+		}, "c a b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var q forceQueue
+			for _, item := range tt.queued {
+				q.seq++
+				q.items = append(q.items, &QueuedForce{Query: item.query, Priority: item.priority, seq: q.seq})
+			}
+			q.sort()
+
+			got := make([]string, len(q.items))
+			for i, item := range q.items {
+				got[i] = item.Query
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("order = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// expectForce reads the next message as a force and returns its query
+func expectForce(t *testing.T, conn *testConn) string {
+	t.Helper()
+
+	var data ForceActionsData
+	if err := json.Unmarshal(conn.expect(CommandForceActions).Data, &data); err != nil {
+		t.Fatalf("invalid force: %v", err)
+	}
+	return data.Query
+}
+
+func TestQueuedForcesAreSentOneAtATime(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, func(config *ClientConfig) {
+		config.QueueForces = true
+		config.QueueTimeout = -1
+	})
+	conn := connect(t, c, s)
+
+	if err := c.RegisterAction(&testAction{name: "jump"}); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+
+	forces := []struct {
+		query    string
+		priority Priority
+	}{
+		{"first", PriorityLow},
+		{"low", PriorityLow},
+		{"high", PriorityHigh},
+	}
+	for _, f := range forces {
+		if err := c.ForceActions(f.query, []string{"jump"}, WithPriority(f.priority)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Each answer releases the next force, highest priority first
+	for i, want := range []string{"first", "high", "low"} {
+		if got := expectForce(t, conn); got != want {
+			t.Fatalf("force %d = %q, want %q", i, got, want)
+		}
+		if queued := c.QueuedForces(); len(queued) != 2-i {
+			t.Fatalf("%d forces queued after force %d, want %d", len(queued), i, 2-i)
+		}
+		conn.sendAction(want, "jump", "")
+		conn.expectResult()
+	}
+
+	waitFor(t, "last force to be answered", func() bool { return c.ActiveForce() == nil })
+}
+
+func TestQueuedForceDroppedWhenStale(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, func(config *ClientConfig) {
+		config.QueueForces = true
+		config.QueueTimeout = -1
+	})
+	conn := connect(t, c, s)
+
+	if err := c.RegisterActions([]ActionHandler{&testAction{name: "jump"}, &testAction{name: "duck"}}); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+
+	if err := c.ForceActions("first", []string{"jump"}); err != nil {
+		t.Fatal(err)
+	}
+	expectForce(t, conn)
+	queued, err := c.QueueForce("second", []string{"duck"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.UnregisterAction("duck"); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandUnregisterActions)
+	conn.sendAction("1", "jump", "")
+	conn.expectResult()
+
+	if err := queued.Err(); !errors.Is(err, ErrForceStale) {
+		t.Errorf("queued force error = %v, want %v", err, ErrForceStale)
+	}
+}
+
+func TestUnansweredForceTimesOut(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, func(config *ClientConfig) {
+		config.QueueForces = true
+		config.QueueTimeout = 50 * time.Millisecond
+	})
+	conn := connect(t, c, s)
+
+	if err := c.RegisterAction(&testAction{name: "jump"}); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+
+	waitErr := make(chan error, 1)
+	go func() {
+		_, err := c.ForceAndWait(context.Background(), "ignored", []string{"jump"})
+		waitErr <- err
+	}()
+	if got := expectForce(t, conn); got != "ignored" {
+		t.Fatalf("force = %q, want ignored", got)
+	}
+
+	queued, err := c.QueueForce("next", []string{"jump"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nobody answers, so the queue timeout sends the next force
+	if got := expectForce(t, conn); got != "next" {
+		t.Fatalf("force = %q, want next", got)
+	}
+	if err := queued.Err(); err != nil {
+		t.Errorf("queued force error = %v, want nil", err)
+	}
+	select {
+	case err := <-waitErr:
+		if !errors.Is(err, ErrForceTimedOut) {
+			t.Errorf("ForceAndWait() = %v, want %v", err, ErrForceTimedOut)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ForceAndWait did not return")
+	}
+}
+
+func TestForceWithoutQueueNeverTimesOut(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, func(config *ClientConfig) {
+		config.QueueForces = true
+		config.QueueTimeout = 20 * time.Millisecond
+	})
+	conn := connect(t, c, s)
+
+	if err := c.RegisterAction(&testAction{name: "jump"}); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+
+	if err := c.ForceActions("alone", []string{"jump"}); err != nil {
+		t.Fatal(err)
+	}
+	expectForce(t, conn)
+
+	// The timeout only applies once another force waits behind the active one
+	time.Sleep(60 * time.Millisecond)
+	if c.ActiveForce() == nil {
+		t.Fatal("force expired with nothing queued behind it")
+	}
+}
@@ -0,0 +1,130 @@
+package neuro
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Waiting for Forces
+
+var (
+	// ErrForceSuperseded is returned by ForceAndWait when a newer force replaces the awaited one
+	ErrForceSuperseded = errors.New("force superseded by a newer force")
+	// ErrTooManyAttempts is returned by ForceAndWait when answers keep failing validation
+	ErrTooManyAttempts = errors.New("too many failed attempts")
+)
+
+// ActionInvocation is a validated action that answered a force
+type ActionInvocation struct {
+	ID   string
+	Name string
+	// Params are the action parameters as sent by Neuro
+	Params json.RawMessage
+	// State is the value returned by the handler's Validate
+	State interface{}
+	// Message is the result message sent to Neuro
+	Message string
+}
+
+// forceWaiter receives the outcome of a force awaited by ForceAndWait.
+// Its fields are guarded by Client.forceMu.
+type forceWaiter struct {
+	maxAttempts int
+	failures    int
+	done        chan forceOutcome
+}
+
+type forceOutcome struct {
+	invocation *ActionInvocation
+	err        error
+}
+
+// finish reports the outcome of the force. Must be called with Client.forceMu held.
+func (w *forceWaiter) finish(invocation *ActionInvocation, err error) {
+	select {
+	case w.done <- forceOutcome{invocation: invocation, err: err}:
+	default:
+	}
+}
+
+// WithMaxAttempts makes ForceAndWait give up after n answers fail validation.
+// Neuro retries a forced action after a failure, so by default ForceAndWait
+// keeps waiting for a valid answer.
+func WithMaxAttempts(n int) ForceOption {
+	return func(c *forceConfig) {
+		c.maxAttempts = n
+	}
+}
+
+// ForceAndWait forces Neuro to execute one of the actions and waits for the
+// first answer that passes validation. The handler's Execute still runs as usual.
+// Answers that fail validation are retried by Neuro; see WithMaxAttempts.
+// It returns ErrForceSuperseded if another force is sent before an answer
+// arrives, ErrForceStale if the actions are unregistered first, ErrForceTimedOut
+// if it holds back queued forces past the queue timeout, and an error wrapping
+// ctx.Err() if ctx is done first.
+func (c *Client) ForceAndWait(ctx context.Context, query string, actionNames []string, opts ...ForceOption) (*ActionInvocation, error) {
+	waiter := &forceWaiter{done: make(chan forceOutcome, 1)}
+	opts = append(opts, func(fc *forceConfig) {
+		waiter.maxAttempts = fc.maxAttempts
+		fc.waiter = waiter
+	})
+
+	if err := c.ForceActions(query, actionNames, opts...); err != nil {
+		return nil, err
+	}
+
+	select {
+	case outcome := <-waiter.done:
+		return outcome.invocation, outcome.err
+	case <-ctx.Done():
+		c.detachWaiter(waiter)
+		return nil, fmt.Errorf("no answer to force %q: %w", query, ctx.Err())
+	}
+}
+
+// detachWaiter stops delivering answers to a waiter that is no longer listening.
+// A queued force is cancelled, but a sent force stays tracked, since Neuro may still answer it.
+func (c *Client) detachWaiter(waiter *forceWaiter) {
+	c.forceQueue.mu.Lock()
+	for i, item := range c.forceQueue.items {
+		if item.config.waiter == waiter {
+			c.forceQueue.items = append(c.forceQueue.items[:i], c.forceQueue.items[i+1:]...)
+			item.drop(ErrForceCancelled)
+			break
+		}
+	}
+	c.forceQueue.mu.Unlock()
+
+	c.forceMu.Lock()
+	defer c.forceMu.Unlock()
+
+	if c.lastForce != nil && c.lastForce.waiter == waiter {
+		c.lastForce.waiter = nil
+	}
+}
+
+// observeForceFailure counts an answer to a force that was not accepted,
+// failing a waiting ForceAndWait once it runs out of attempts
+func (c *Client) observeForceFailure(force *activeForce, rec ActionRecord) {
+	switch rec.Outcome {
+	case OutcomeValidationFailed, OutcomeInvalidData, OutcomeUnknownAction, OutcomeUnavailable:
+	default:
+		return
+	}
+
+	c.forceMu.Lock()
+	defer c.forceMu.Unlock()
+
+	w := force.waiter
+	if w == nil || w.maxAttempts <= 0 {
+		return
+	}
+	w.failures++
+	if w.failures >= w.maxAttempts {
+		w.finish(nil, fmt.Errorf("%w (%d): last failure: %s", ErrTooManyAttempts, w.failures, rec.ResultMessage))
+		force.waiter = nil
+	}
+}
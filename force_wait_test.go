@@ -0,0 +1,183 @@
+package neuro
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestForceAndWait(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []ForceOption
+		timeout time.Duration
+		// act plays Neuro's side after the force was read; cancel cancels the wait
+		act     func(t *testing.T, c *Client, conn *testConn, cancel context.CancelFunc)
+		wantID  string
+		wantErr error
+	}{
+		{
+			name: "answer after a failed attempt",
+			act: func(t *testing.T, c *Client, conn *testConn, cancel context.CancelFunc) {
+				conn.sendAction("1", "pick", `{"ok": false}`)
+				conn.expectResult()
+				conn.sendAction("2", "pick", `{"ok": true}`)
+				conn.expectResult()
+			},
+			wantID: "2",
+		},
+		{
+			name: "too many attempts",
+			opts: []ForceOption{WithMaxAttempts(2)},
+			act: func(t *testing.T, c *Client, conn *testConn, cancel context.CancelFunc) {
+				for _, id := range []string{"1", "2"} {
+					conn.sendAction(id, "pick", `{"ok": false}`)
+					conn.expectResult()
+				}
+			},
+			wantErr: ErrTooManyAttempts,
+		},
+		{
+			name: "superseded",
+			act: func(t *testing.T, c *Client, conn *testConn, cancel context.CancelFunc) {
+				if err := c.ForceActions("Other", []string{"pick"}); err != nil {
+					t.Error(err)
+				}
+			},
+			wantErr: ErrForceSuperseded,
+		},
+		{
+			name: "actions unregistered",
+			act: func(t *testing.T, c *Client, conn *testConn, cancel context.CancelFunc) {
+				if err := c.UnregisterAction("pick"); err != nil {
+					t.Error(err)
+				}
+			},
+			wantErr: ErrForceStale,
+		},
+		{
+			name:    "timeout",
+			timeout: 20 * time.Millisecond,
+			act:     func(*testing.T, *Client, *testConn, context.CancelFunc) {},
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name: "cancelled",
+			act: func(t *testing.T, c *Client, conn *testConn, cancel context.CancelFunc) {
+				cancel()
+			},
+			wantErr: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			c := newTestClient(t, s, nil)
+			conn := connect(t, c, s)
+			if err := c.RegisterAction(pickyAction{}); err != nil {
+				t.Fatal(err)
+			}
+			conn.expect(CommandRegisterActions)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.timeout > 0 {
+				ctx, cancel = context.WithTimeout(context.Background(), tt.timeout)
+			}
+			defer cancel()
+
+			done := make(chan promptResult, 1)
+			go func() {
+				invocation, err := c.ForceAndWait(ctx, "Pick", []string{"pick"}, tt.opts...)
+				done <- promptResult{invocation, err}
+			}()
+			readForce(t, conn)
+			tt.act(t, c, conn, cancel)
+
+			var got promptResult
+			select {
+			case got = <-done:
+			case <-time.After(2 * time.Second):
+				t.Fatal("ForceAndWait did not return")
+			}
+
+			if tt.wantErr != nil {
+				if !errors.Is(got.err, tt.wantErr) {
+					t.Errorf("ForceAndWait() error = %v, want %v", got.err, tt.wantErr)
+				}
+				return
+			}
+			if got.err != nil {
+				t.Fatal(got.err)
+			}
+			invocation := got.value.(*ActionInvocation)
+			if invocation.ID != tt.wantID || invocation.Name != "pick" || string(invocation.Params) != `{"ok": true}` {
+				t.Errorf("invocation = %+v, want pick %s", invocation, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestForceAndWaitCancelKeepsForce(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+	if err := c.RegisterAction(pickyAction{}); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.ForceAndWait(ctx, "Pick", []string{"pick"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ForceAndWait() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	readForce(t, conn)
+
+	// Neuro may still answer the sent force after the caller stopped waiting
+	if c.ActiveForce() == nil {
+		t.Fatal("the force was dropped when the wait ended")
+	}
+	conn.sendAction("1", "pick", `{"ok": true}`)
+	if result := conn.expectResult(); !result.Success {
+		t.Errorf("late answer failed: %+v", result)
+	}
+	waitFor(t, "force to be answered", func() bool { return c.ActiveForce() == nil })
+}
+
+func TestForceAndWaitCancelDropsQueuedForce(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, func(config *ClientConfig) {
+		config.QueueForces = true
+		config.QueueTimeout = -1
+	})
+	conn := connect(t, c, s)
+	if err := c.RegisterAction(pickyAction{}); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+
+	if err := c.ForceActions("First", []string{"pick"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := expectForce(t, conn); got != "First" {
+		t.Fatalf("force = %q, want First", got)
+	}
+
+	// The second force waits in the queue until the wait is cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.ForceAndWait(ctx, "Second", []string{"pick"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ForceAndWait() = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// Answering the first force must not send the cancelled one
+	conn.sendAction("1", "pick", `{"ok": true}`)
+	conn.expectResult()
+	waitFor(t, "force to be answered", func() bool { return c.ActiveForce() == nil })
+	if err := c.SendContext("done", true); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandContext)
+}
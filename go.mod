@@ -2,4 +2,7 @@ module github.com/cassitly/neuro-integration-sdk
 
 go 1.21
 
-require github.com/gorilla/websocket v1.5.3
+require (
+	github.com/gorilla/websocket v1.5.3
+	gopkg.in/yaml.v3 v3.0.1
+)
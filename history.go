@@ -0,0 +1,263 @@
+package neuro
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Action History
+
+// ActionOutcome is how the handling of an incoming action ended
+type ActionOutcome string
+
+const (
+	// OutcomeExecuted means the action passed validation and was executed
+	OutcomeExecuted ActionOutcome = "executed"
+	// OutcomeValidationFailed means Validate rejected the action
+	OutcomeValidationFailed ActionOutcome = "validation_failed"
+	// OutcomeUnknownAction means no handler was registered for the action
+	OutcomeUnknownAction ActionOutcome = "unknown_action"
+	// OutcomeInvalidData means the action data was not valid JSON
+	OutcomeInvalidData ActionOutcome = "invalid_data"
+	// OutcomeUnavailable means the action was not available, e.g. because its
+	// IsAvailable predicate was false or its scene was switched away from
+	OutcomeUnavailable ActionOutcome = "unavailable"
+	// OutcomeDuplicate means the action ID had already been received and the action was ignored
+	OutcomeDuplicate ActionOutcome = "duplicate"
+	// OutcomeExpired means the action was failed automatically because no result was sent in time
+	OutcomeExpired ActionOutcome = "expired"
+	// OutcomeResultFailed means the action result could not be sent
+	OutcomeResultFailed ActionOutcome = "result_failed"
+)
+
+// ForceRecord describes the action force an action answered
+type ForceRecord struct {
+	Query       string    `json:"query"`
+	ActionNames []string  `json:"action_names"`
+	Priority    Priority  `json:"priority"`
+	ForcedAt    time.Time `json:"forced_at"`
+}
+
+// ActionRecord is a single entry in the action history
+type ActionRecord struct {
+	ID       string          `json:"id"`
+	Name     string          `json:"name"`
+	Params   json.RawMessage `json:"params,omitempty"`
+	Received time.Time       `json:"received"`
+	Outcome  ActionOutcome   `json:"outcome"`
+	// ResultMessage is the message sent to Neuro in the action result
+	ResultMessage string `json:"result_message,omitempty"`
+	// ValidationTime and ExecutionTime are zero if the step did not run
+	ValidationTime time.Duration `json:"validation_time,omitempty"`
+	ExecutionTime  time.Duration `json:"execution_time,omitempty"`
+	// Force is the force this action answered, if any
+	Force *ForceRecord `json:"force,omitempty"`
+}
+
+// HistoryQuery selects records from an ActionHistory.
+// Zero-valued fields match everything.
+type HistoryQuery struct {
+	Name     string
+	Since    time.Time
+	Until    time.Time
+	Outcomes []ActionOutcome
+	// Limit keeps only the most recent matches (0 means no limit)
+	Limit int
+}
+
+// Matches reports whether a record satisfies the query filters (Limit is ignored)
+func (q HistoryQuery) Matches(r ActionRecord) bool {
+	if q.Name != "" && r.Name != q.Name {
+		return false
+	}
+	if !q.Since.IsZero() && r.Received.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && !r.Received.Before(q.Until) {
+		return false
+	}
+	if len(q.Outcomes) > 0 {
+		found := false
+		for _, o := range q.Outcomes {
+			if r.Outcome == o {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// limit keeps the last q.Limit records
+func (q HistoryQuery) limit(records []ActionRecord) []ActionRecord {
+	if q.Limit > 0 && len(records) > q.Limit {
+		return records[len(records)-q.Limit:]
+	}
+	return records
+}
+
+// ActionHistory stores action records and answers queries over them.
+// Query results are ordered oldest first.
+type ActionHistory interface {
+	Record(r ActionRecord) error
+	Query(q HistoryQuery) ([]ActionRecord, error)
+}
+
+// MemoryHistory keeps the most recent records in a fixed-size ring buffer
+type MemoryHistory struct {
+	mu      sync.RWMutex
+	records []ActionRecord
+	next    int
+	full    bool
+}
+
+// NewMemoryHistory creates a ring buffer holding up to capacity records
+func NewMemoryHistory(capacity int) *MemoryHistory {
+	if capacity <= 0 {
+		capacity = 1024
+	}
+	return &MemoryHistory{records: make([]ActionRecord, capacity)}
+}
+
+// Record implements ActionHistory
+func (h *MemoryHistory) Record(r ActionRecord) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.records[h.next] = r
+	h.next = (h.next + 1) % len(h.records)
+	if h.next == 0 {
+		h.full = true
+	}
+	return nil
+}
+
+// Query implements ActionHistory
+func (h *MemoryHistory) Query(q HistoryQuery) ([]ActionRecord, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	var matches []ActionRecord
+	visit := func(r ActionRecord) {
+		if q.Matches(r) {
+			matches = append(matches, r)
+		}
+	}
+
+	if h.full {
+		for _, r := range h.records[h.next:] {
+			visit(r)
+		}
+	}
+	for _, r := range h.records[:h.next] {
+		visit(r)
+	}
+
+	return q.limit(matches), nil
+}
+
+// FileHistory appends records to a JSONL file and answers queries by scanning it
+type FileHistory struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// OpenFileHistory opens (or creates) a JSONL history file for appending
+func OpenFileHistory(path string) (*FileHistory, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	return &FileHistory{path: path, file: f}, nil
+}
+
+// Record implements ActionHistory
+func (h *FileHistory) Record(r ActionRecord) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to encode history record: %w", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.file == nil {
+		return errors.New("history is closed")
+	}
+	if _, err := h.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Query implements ActionHistory
+func (h *FileHistory) Query(q HistoryQuery) ([]ActionRecord, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	f, err := os.Open(h.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	return scanHistory(f, q)
+}
+
+// Close closes the history file
+func (h *FileHistory) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.file == nil {
+		return nil
+	}
+	err := h.file.Close()
+	h.file = nil
+	return err
+}
+
+// scanHistory reads JSONL records and returns those matching q
+func scanHistory(r io.Reader, q HistoryQuery) ([]ActionRecord, error) {
+	var matches []ActionRecord
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec ActionRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("invalid history record on line %d: %w", line, err)
+		}
+		if q.Matches(rec) {
+			matches = append(matches, rec)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	return q.limit(matches), nil
+}
+
+// recordHistory stores a finished action record in the configured history, if any
+func (c *Client) recordHistory(r ActionRecord) {
+	if c.config.History == nil {
+		return
+	}
+	if err := c.config.History.Record(r); err != nil {
+		c.logger.Warn("Failed to record action history", "action", r.Name, "action_id", r.ID, "error", err)
+	}
+}
@@ -0,0 +1,218 @@
+package neuro
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// recordIDs returns the IDs of records in order
+func recordIDs(records []ActionRecord) []string {
+	ids := make([]string, len(records))
+	for i, r := range records {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+func TestMemoryHistoryRingBuffer(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		records  int
+		want     []string
+	}{
+		{"empty", 3, 0, []string{}},
+		{"below capacity", 3, 2, []string{"0", "1"}},
+		{"at capacity", 3, 3, []string{"0", "1", "2"}},
+		{"wrapped once", 3, 4, []string{"1", "2", "3"}},
+		{"wrapped twice", 3, 7, []string{"4", "5", "6"}},
+		{"capacity of one", 1, 5, []string{"4"}},
+		{"default capacity", 0, 1030, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewMemoryHistory(tt.capacity)
+			for i := 0; i < tt.records; i++ {
+				h.Record(ActionRecord{ID: fmt.Sprint(i)})
+			}
+
+			records, err := h.Query(HistoryQuery{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == nil {
+				// Only the default number of records is kept, the oldest dropped
+				if len(records) != 1024 || records[0].ID != "6" {
+					t.Errorf("kept %d records starting at %s, want 1024 starting at 6", len(records), records[0].ID)
+				}
+				return
+			}
+			if got := recordIDs(records); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("records = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistoryQuery(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	records := []ActionRecord{
+		{ID: "1", Name: "jump", Received: start, Outcome: OutcomeExecuted},
+		{ID: "2", Name: "duck", Received: start.Add(time.Minute), Outcome: OutcomeValidationFailed},
+		{ID: "3", Name: "jump", Received: start.Add(2 * time.Minute), Outcome: OutcomeValidationFailed},
+		{ID: "4", Name: "jump", Received: start.Add(3 * time.Minute), Outcome: OutcomeExecuted},
+	}
+
+	queries := []struct {
+		name  string
+		query HistoryQuery
+		want  []string
+	}{
+		{"everything", HistoryQuery{}, []string{"1", "2", "3", "4"}},
+		{"by name", HistoryQuery{Name: "jump"}, []string{"1", "3", "4"}},
+		{"since is inclusive", HistoryQuery{Since: start.Add(time.Minute)}, []string{"2", "3", "4"}},
+		{"until is exclusive", HistoryQuery{Until: start.Add(time.Minute)}, []string{"1"}},
+		{"by outcome", HistoryQuery{Outcomes: []ActionOutcome{OutcomeValidationFailed}}, []string{"2", "3"}},
+		{"limit keeps the latest", HistoryQuery{Name: "jump", Limit: 2}, []string{"3", "4"}},
+		{"no match", HistoryQuery{Name: "run"}, []string{}},
+	}
+
+	stores := []struct {
+		name string
+		open func(t *testing.T) ActionHistory
+	}{
+		{"memory", func(*testing.T) ActionHistory { return NewMemoryHistory(10) }},
+		{"file", func(t *testing.T) ActionHistory {
+			h, err := OpenFileHistory(filepath.Join(t.TempDir(), "history.jsonl"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { h.Close() })
+			return h
+		}},
+	}
+
+	for _, store := range stores {
+		t.Run(store.name, func(t *testing.T) {
+			h := store.open(t)
+			for _, r := range records {
+				if err := h.Record(r); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for _, q := range queries {
+				t.Run(q.name, func(t *testing.T) {
+					got, err := h.Query(q.query)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if ids := recordIDs(got); !reflect.DeepEqual(ids, q.want) {
+						t.Errorf("Query() = %v, want %v", ids, q.want)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestFileHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+
+	h, err := OpenFileHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Record(ActionRecord{ID: "1", Name: "jump", Params: []byte(`{"height":2}`), ExecutionTime: time.Second})
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Record(ActionRecord{ID: "2"}); err == nil {
+		t.Error("Record() succeeded after Close")
+	}
+
+	// Reopening appends to the existing records
+	h, err = OpenFileHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	h.Record(ActionRecord{ID: "3", Name: "duck"})
+
+	records, err := h.Query(HistoryQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := recordIDs(records); !reflect.DeepEqual(got, []string{"1", "3"}) {
+		t.Fatalf("records = %v, want [1 3]", got)
+	}
+	if string(records[0].Params) != `{"height":2}` || records[0].ExecutionTime != time.Second {
+		t.Errorf("record did not round-trip: %+v", records[0])
+	}
+
+	// A corrupt line is reported rather than skipped
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("not json\n")
+	f.Close()
+	if _, err := h.Query(HistoryQuery{}); err == nil {
+		t.Error("Query() succeeded with a corrupt line")
+	}
+}
+
+func TestClientHistory(t *testing.T) {
+	history := NewMemoryHistory(10)
+	s := newTestServer(t)
+	c := newTestClient(t, s, func(config *ClientConfig) { config.History = history })
+	conn := connect(t, c, s)
+	if err := c.RegisterAction(pickyAction{}); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+
+	if err := c.ForceActions("Pick one", []string{"pick"}); err != nil {
+		t.Fatal(err)
+	}
+	readForce(t, conn)
+
+	for i, data := range []struct{ name, data string }{
+		{"pick", `{"ok": false}`},
+		{"pick", `{"ok": true}`},
+		{"missing", ""},
+		{"pick", "{"},
+	} {
+		conn.sendAction(fmt.Sprint(i+1), data.name, data.data)
+		conn.expectResult()
+	}
+	waitFor(t, "all records", func() bool {
+		records, _ := history.Query(HistoryQuery{})
+		return len(records) == 4
+	})
+
+	// Actions are handled concurrently, so records may be stored out of order
+	records, _ := history.Query(HistoryQuery{})
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	want := []ActionOutcome{OutcomeValidationFailed, OutcomeExecuted, OutcomeUnknownAction, OutcomeInvalidData}
+	for i, r := range records {
+		if r.Outcome != want[i] {
+			t.Errorf("record %s outcome = %s, want %s", r.ID, r.Outcome, want[i])
+		}
+	}
+	if f := records[0].Force; f == nil || f.Query != "Pick one" {
+		t.Errorf("first attempt force = %+v, want the pending force", f)
+	}
+	if string(records[1].Params) != `{"ok": true}` || records[1].ResultMessage != "" {
+		t.Errorf("executed record = %+v", records[1])
+	}
+	if records[2].Force != nil {
+		t.Errorf("unknown action answered force %+v", records[2].Force)
+	}
+}
@@ -0,0 +1,161 @@
+package neuro
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// Action Linting
+
+// LintIssue is a problem found in an action definition
+type LintIssue struct {
+	Action string
+	// Path locates the problem within the schema, e.g. "properties.item.enum"
+	Path    string
+	Message string
+	// Warning marks a convention that is not followed. Neuro still accepts the
+	// action, so only issues that are not warnings reject a manifest.
+	Warning bool
+}
+
+func (i LintIssue) Error() string {
+	prefix := ""
+	if i.Warning {
+		prefix = "warning: "
+	}
+	if i.Path == "" {
+		return fmt.Sprintf("%saction %q: %s", prefix, i.Action, i.Message)
+	}
+	return fmt.Sprintf("%saction %q: %s: %s", prefix, i.Action, i.Path, i.Message)
+}
+
+// LintErrors returns the issues that are not warnings
+func LintErrors(issues []LintIssue) []LintIssue {
+	var errs []LintIssue
+	for _, issue := range issues {
+		if !issue.Warning {
+			errs = append(errs, issue)
+		}
+	}
+	return errs
+}
+
+// unsupportedSchemaKeywords are JSON schema keywords the Neuro API probably does not support.
+// "description" is left out: property descriptions are common and harmless.
+var unsupportedSchemaKeywords = map[string]bool{
+	"$anchor": true, "$comment": true, "$defs": true, "$dynamicAnchor": true,
+	"$dynamicRef": true, "$id": true, "$ref": true, "$schema": true,
+	"$vocabulary": true, "additionalProperties": true, "allOf": true, "anyOf": true,
+	"contentEncoding": true, "contentMediaType": true, "contentSchema": true,
+	"dependentRequired": true, "dependentSchemas": true, "deprecated": true,
+	"else": true, "if": true, "maxProperties": true, "minProperties": true,
+	"multipleOf": true, "not": true, "oneOf": true, "patternProperties": true,
+	"readOnly": true, "then": true, "title": true, "unevaluatedItems": true,
+	"unevaluatedProperties": true, "writeOnly": true,
+}
+
+var actionNamePattern = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// LintActions checks action definitions against the Neuro API rules and returns
+// every issue found. Empty or duplicate names, missing descriptions, schemas that
+// are not objects, required properties that are not defined and empty enums are
+// errors. Names that are not lowercase and unsupported schema keywords are warnings.
+func LintActions(defs []ActionDefinition) []LintIssue {
+	var issues []LintIssue
+	seen := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		if def.Name != "" && seen[def.Name] {
+			issues = append(issues, LintIssue{Action: def.Name, Message: "defined more than once"})
+		}
+		seen[def.Name] = true
+		issues = append(issues, LintAction(def)...)
+	}
+	return issues
+}
+
+// LintAction checks a single action definition. See LintActions.
+func LintAction(def ActionDefinition) []LintIssue {
+	var issues []LintIssue
+	add := func(path, format string, args ...interface{}) {
+		issues = append(issues, LintIssue{Action: def.Name, Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	warn := func(path, format string, args ...interface{}) {
+		issues = append(issues, LintIssue{Action: def.Name, Path: path, Message: fmt.Sprintf(format, args...), Warning: true})
+	}
+
+	if def.Name == "" {
+		add("", "name cannot be empty")
+	} else if !actionNamePattern.MatchString(def.Name) {
+		warn("", "name should be lowercase with words separated by underscores or dashes")
+	}
+	if def.Description == "" {
+		add("", "description cannot be empty")
+	}
+
+	if def.Schema == nil {
+		return issues
+	}
+	if def.Schema.Type != "object" {
+		add("type", "schema type must be \"object\", not %q", def.Schema.Type)
+	}
+	for _, name := range def.Schema.Required {
+		if _, ok := def.Schema.Properties[name]; !ok {
+			add("required", "required property %q is not defined", name)
+		}
+	}
+	for _, name := range sortedKeys(def.Schema.Properties) {
+		lintSchemaValue("properties."+name, def.Schema.Properties[name], add, warn)
+	}
+
+	return issues
+}
+
+// lintSchemaValue checks a property schema and the schemas nested in it
+func lintSchemaValue(path string, value interface{}, add, warn func(path, format string, args ...interface{})) {
+	schema, ok := value.(map[string]interface{})
+	if !ok {
+		add(path, "property schema must be an object")
+		return
+	}
+
+	keys := make([]string, 0, len(schema))
+	for k := range schema {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if unsupportedSchemaKeywords[k] {
+			warn(path+"."+k, "keyword is probably not supported by Neuro")
+		}
+	}
+
+	if enum, ok := schema["enum"]; ok && isEmptyList(enum) {
+		add(path+".enum", "enum cannot be empty")
+	}
+	if props, ok := schema["properties"].(map[string]interface{}); ok {
+		for _, name := range sortedKeys(props) {
+			lintSchemaValue(path+".properties."+name, props[name], add, warn)
+		}
+	}
+	if items, ok := schema["items"]; ok {
+		lintSchemaValue(path+".items", items, add, warn)
+	}
+}
+
+// isEmptyList reports whether v is an empty list of any element type
+func isEmptyList(v interface{}) bool {
+	switch l := v.(type) {
+	case nil:
+		return true
+	case []interface{}:
+		return len(l) == 0
+	case []string:
+		return len(l) == 0
+	case []int:
+		return len(l) == 0
+	default:
+		return false
+	}
+}
@@ -0,0 +1,52 @@
+package neuro
+
+import "testing"
+
+func TestLintAction(t *testing.T) {
+	object := func(props map[string]interface{}, required ...string) *ActionSchema {
+		return &ActionSchema{Type: "object", Properties: props, Required: required}
+	}
+
+	tests := []struct {
+		name     string
+		def      ActionDefinition
+		errors   int
+		warnings int
+	}{
+		{"valid", ActionDefinition{Name: "buy_item", Description: "Buy", Schema: object(map[string]interface{}{
+			"item": map[string]interface{}{"type": "string", "enum": []interface{}{"sword"}},
+		}, "item")}, 0, 0},
+		{"empty name", ActionDefinition{Description: "Buy"}, 1, 0},
+		{"uppercase name", ActionDefinition{Name: "Buy Item", Description: "Buy"}, 0, 1},
+		{"empty description", ActionDefinition{Name: "buy"}, 1, 0},
+		{"not an object", ActionDefinition{Name: "buy", Description: "Buy", Schema: &ActionSchema{Type: "string"}}, 1, 0},
+		{"undefined required", ActionDefinition{Name: "buy", Description: "Buy", Schema: object(nil, "item")}, 1, 0},
+		{"empty enum", ActionDefinition{Name: "buy", Description: "Buy", Schema: object(map[string]interface{}{
+			"item": map[string]interface{}{"enum": []interface{}{}},
+		})}, 1, 0},
+		{"property not an object", ActionDefinition{Name: "buy", Description: "Buy", Schema: object(map[string]interface{}{
+			"item": "string",
+		})}, 1, 0},
+		{"unsupported keyword", ActionDefinition{Name: "buy", Description: "Buy", Schema: object(map[string]interface{}{
+			"item": map[string]interface{}{"type": "string", "title": "Item"},
+		})}, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := LintAction(tt.def)
+			errors := len(LintErrors(issues))
+			if warnings := len(issues) - errors; errors != tt.errors || warnings != tt.warnings {
+				t.Errorf("LintAction() = %v, want %d error(s) and %d warning(s)", issues, tt.errors, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestLintActionsDuplicate(t *testing.T) {
+	def := ActionDefinition{Name: "buy", Description: "Buy"}
+	issues := LintActions([]ActionDefinition{def, def})
+	if len(issues) != 1 || issues[0].Warning {
+		t.Errorf("LintActions() = %v, want one error for the duplicate", issues)
+	}
+}
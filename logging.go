@@ -0,0 +1,121 @@
+package neuro
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"log/slog"
+)
+
+// Logging
+
+// PayloadRedactor rewrites a raw message payload before it is logged.
+// It receives the message command and the full JSON-encoded message and
+// returns what should appear in the log. It must not modify payload in place.
+type PayloadRedactor func(command string, payload []byte) []byte
+
+// RedactAll is a PayloadRedactor that hides every payload
+func RedactAll(command string, payload []byte) []byte {
+	return []byte("[redacted]")
+}
+
+// RedactFields returns a PayloadRedactor that replaces the given keys
+// anywhere in the message (including inside JSON-stringified action data)
+// with "[redacted]"
+func RedactFields(fields ...string) PayloadRedactor {
+	hidden := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		hidden[f] = true
+	}
+
+	return func(command string, payload []byte) []byte {
+		var v interface{}
+		if err := json.Unmarshal(payload, &v); err != nil {
+			return []byte("[redacted: unparseable payload]")
+		}
+		out, err := json.Marshal(redactValue(v, hidden))
+		if err != nil {
+			return []byte("[redacted: unparseable payload]")
+		}
+		return out
+	}
+}
+
+func redactValue(v interface{}, hidden map[string]bool) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, inner := range val {
+			if hidden[k] {
+				val[k] = "[redacted]"
+				continue
+			}
+			val[k] = redactValue(inner, hidden)
+		}
+		return val
+	case []interface{}:
+		for i, inner := range val {
+			val[i] = redactValue(inner, hidden)
+		}
+		return val
+	case string:
+		// Action data is JSON-stringified, so look inside it as well
+		var nested interface{}
+		if err := json.Unmarshal([]byte(val), &nested); err != nil {
+			return val
+		}
+		switch nested.(type) {
+		case map[string]interface{}, []interface{}:
+		default:
+			return val
+		}
+		out, err := json.Marshal(redactValue(nested, hidden))
+		if err != nil {
+			return val
+		}
+		return string(out)
+	default:
+		return val
+	}
+}
+
+// newLogger builds the structured logger used by the client
+func newLogger(config ClientConfig) *slog.Logger {
+	handler := config.LogHandler
+	if handler == nil {
+		if config.Logger != nil {
+			handler = slog.NewTextHandler(legacyWriter{config.Logger}, &slog.HandlerOptions{
+				// The *log.Logger adds its own timestamp
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if len(groups) == 0 && a.Key == slog.TimeKey {
+						return slog.Attr{}
+					}
+					return a
+				},
+			})
+		} else {
+			handler = slog.Default().Handler()
+		}
+	}
+	return slog.New(handler).With("game", config.Game)
+}
+
+// legacyWriter forwards slog output to a *log.Logger so its prefix and flags are kept
+type legacyWriter struct {
+	logger *log.Logger
+}
+
+func (w legacyWriter) Write(p []byte) (int, error) {
+	w.logger.Print(string(p))
+	return len(p), nil
+}
+
+// logPayload logs a raw message payload at debug level, applying the redaction hook
+func (c *Client) logPayload(msg string, command string, payload []byte) {
+	if !c.logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	if c.config.RedactPayload != nil {
+		payload = c.config.RedactPayload(command, payload)
+	}
+	c.logger.Debug(msg, "command", command, "payload", string(payload))
+}
@@ -0,0 +1,116 @@
+package neuro
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRedactFields(t *testing.T) {
+	redact := RedactFields("token", "password")
+
+	tests := []struct {
+		name    string
+		payload string
+		want    string
+	}{
+		{"top level", `{"token": "abc", "game": "Test"}`, `{"token": "[redacted]", "game": "Test"}`},
+		{"nested object", `{"data": {"user": {"password": "hunter2", "name": "vedal"}}}`,
+			`{"data": {"user": {"password": "[redacted]", "name": "vedal"}}}`},
+		{"array of objects", `{"data": {"users": [{"password": "a"}, {"password": "b", "id": 1}]}}`,
+			`{"data": {"users": [{"password": "[redacted]"}, {"password": "[redacted]", "id": 1}]}}`},
+		{"hidden array", `{"token": ["a", "b"]}`, `{"token": "[redacted]"}`},
+		{"stringified object", `{"data": {"data": "{\"password\": \"hunter2\"}"}}`,
+			`{"data": {"data": "{\"password\":\"[redacted]\"}"}}`},
+		{"stringified array", `{"data": {"data": "[{\"token\": \"abc\"}, 2]"}}`,
+			`{"data": {"data": "[{\"token\":\"[redacted]\"},2]"}}`},
+		{"plain strings", `{"data": "hello", "list": ["1", "x"]}`, `{"data": "hello", "list": ["1", "x"]}`},
+		{"unparseable", `{"token": `, `[redacted: unparseable payload]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := redact("action", []byte(tt.payload))
+			if tt.want == "[redacted: unparseable payload]" {
+				if string(got) != tt.want {
+					t.Errorf("redacted %s to %s, want %s", tt.payload, got, tt.want)
+				}
+				return
+			}
+			equal, err := jsonEqual(got, []byte(tt.want))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equal {
+				t.Errorf("redacted %s to %s, want %s", tt.payload, got, tt.want)
+			}
+		})
+	}
+}
+
+// syncBuffer is a bytes.Buffer that log handlers can write to concurrently
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestPayloadLogging(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  slog.Level
+		redact PayloadRedactor
+		want   []string
+		hidden []string
+	}{
+		{"redacted fields", slog.LevelDebug, RedactFields("password"),
+			[]string{"Received message", "Sending message", "[redacted]", "login"}, []string{"hunter2"}},
+		{"redact all", slog.LevelDebug, RedactAll, []string{"payload=[redacted]"}, []string{"hunter2"}},
+		{"no redactor", slog.LevelDebug, nil, []string{"hunter2"}, nil},
+		{"info level", slog.LevelInfo, nil, nil, []string{"hunter2", "Received message"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := &syncBuffer{}
+			s := newTestServer(t)
+			c := newTestClient(t, s, func(config *ClientConfig) {
+				config.LogHandler = slog.NewTextHandler(logs, &slog.HandlerOptions{Level: tt.level})
+				config.RedactPayload = tt.redact
+			})
+			conn := connect(t, c, s)
+			if err := c.RegisterAction(&testAction{name: "login"}); err != nil {
+				t.Fatal(err)
+			}
+			conn.expect(CommandRegisterActions)
+
+			conn.sendAction("1", "login", `{"password": "hunter2"}`)
+			conn.expectResult()
+
+			out := logs.String()
+			for _, text := range tt.want {
+				if !strings.Contains(out, text) {
+					t.Errorf("logs do not contain %q:\n%s", text, out)
+				}
+			}
+			for _, text := range tt.hidden {
+				if strings.Contains(out, text) {
+					t.Errorf("logs contain %q:\n%s", text, out)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,433 @@
+package neuro
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Action Manifests
+
+// ActionFunc handles an action declared in a manifest. It runs like Execute, once the
+// parameters passed the manifest's checks and the successful result was sent, so an
+// error it returns can no longer fail the action and is only logged.
+type ActionFunc func(data json.RawMessage) error
+
+// ManifestAction is an action declared in a manifest
+type ManifestAction struct {
+	ActionDefinition
+	// Handler is the name of the ActionFunc bound to the action (default the action name),
+	// so an action can be renamed without changing code
+	Handler string `json:"handler,omitempty"`
+}
+
+// HandlerName returns the name of the ActionFunc bound to the action
+func (a ManifestAction) HandlerName() string {
+	if a.Handler != "" {
+		return a.Handler
+	}
+	return a.Name
+}
+
+// Manifest is a list of action definitions loaded from a YAML or JSON file:
+//
+//	actions:
+//	  - name: buy_item
+//	    handler: buy
+//	    description: Buy an item from the shop
+//	    schema:
+//	      type: object
+//	      properties:
+//	        item:
+//	          type: string
+//	          enum: [sword, shield]
+//	      required: [item]
+type Manifest struct {
+	Actions []ManifestAction `json:"actions"`
+}
+
+// ParseManifest parses a manifest from YAML or JSON. Unknown fields are rejected.
+func ParseManifest(data []byte) (*Manifest, error) {
+	// YAML is a superset of JSON, so one parser handles both. Converting through
+	// JSON keeps the schema maps in the same shape as hand-written definitions.
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	b, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	var m Manifest
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// LoadManifest reads and parses a manifest file
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	m, err := ParseManifest(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Definitions returns the action definitions of the manifest
+func (m *Manifest) Definitions() []ActionDefinition {
+	defs := make([]ActionDefinition, len(m.Actions))
+	for i, a := range m.Actions {
+		defs[i] = a.ActionDefinition
+	}
+	return defs
+}
+
+// Lint checks the manifest with LintActions and returns every error as one error.
+// Warnings are left out, see Warnings.
+func (m *Manifest) Lint() error {
+	issues := LintErrors(LintActions(m.Definitions()))
+	if len(issues) == 0 {
+		return nil
+	}
+	errs := make([]error, len(issues))
+	for i, issue := range issues {
+		errs[i] = issue
+	}
+	return fmt.Errorf("manifest has %d issue(s): %w", len(issues), errors.Join(errs...))
+}
+
+// Warnings returns the lint issues that do not stop the manifest from loading
+func (m *Manifest) Warnings() []LintIssue {
+	var warnings []LintIssue
+	for _, issue := range LintActions(m.Definitions()) {
+		if issue.Warning {
+			warnings = append(warnings, issue)
+		}
+	}
+	return warnings
+}
+
+// Bind lints the manifest and binds each action to the func named by its handler.
+// Only lint errors stop it, not warnings.
+func (m *Manifest) Bind(funcs map[string]ActionFunc) ([]ActionHandler, error) {
+	if err := m.Lint(); err != nil {
+		return nil, err
+	}
+
+	handlers := make([]ActionHandler, len(m.Actions))
+	for i, a := range m.Actions {
+		fn, ok := funcs[a.HandlerName()]
+		if !ok {
+			return nil, fmt.Errorf("no handler func %q for action %q", a.HandlerName(), a.Name)
+		}
+		handlers[i] = &manifestHandler{manifestBinding: manifestBinding{def: a.ActionDefinition, handler: a.HandlerName(), fn: fn}}
+	}
+	return handlers, nil
+}
+
+// manifestHandler dispatches a manifest action to its ActionFunc. The definition
+// is replaced in place on reload so the registration stays the same.
+type manifestHandler struct {
+	mu sync.RWMutex
+	manifestBinding
+	logger *slog.Logger // receives func errors (nil discards them)
+}
+
+// manifestBinding is a manifest action's definition and the func bound to it
+type manifestBinding struct {
+	def     ActionDefinition
+	handler string
+	fn      ActionFunc
+}
+
+func (h *manifestHandler) definition() ActionDefinition {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.def
+}
+
+func (h *manifestHandler) binding() manifestBinding {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.manifestBinding
+}
+
+func (h *manifestHandler) update(b manifestBinding) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.manifestBinding = b
+}
+
+func (h *manifestHandler) GetName() string {
+	return h.definition().Name
+}
+
+func (h *manifestHandler) GetDescription() string {
+	return h.definition().Description
+}
+
+func (h *manifestHandler) GetSchema() *ActionSchema {
+	return h.definition().Schema
+}
+
+// Validate checks required properties and enums from the manifest, since they can
+// change without the func knowing, and passes the parameters on to Execute
+func (h *manifestHandler) Validate(data json.RawMessage) (interface{}, ExecutionResult) {
+	h.mu.RLock()
+	schema := h.def.Schema
+	h.mu.RUnlock()
+
+	if schema != nil {
+		var params map[string]interface{}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &params); err != nil {
+				return nil, NewFailureResult("Parameters must be a JSON object")
+			}
+		}
+		if msg := checkManifestParams(schema, params); msg != "" {
+			return nil, NewFailureResult(msg)
+		}
+	}
+
+	return data, NewSuccessResult("")
+}
+
+// Execute calls the bound func with the parameters checked by Validate
+func (h *manifestHandler) Execute(state interface{}) {
+	h.mu.RLock()
+	name, fn, logger := h.def.Name, h.fn, h.logger
+	h.mu.RUnlock()
+
+	data, _ := state.(json.RawMessage)
+	if err := fn(data); err != nil && logger != nil {
+		logger.Warn("Action func failed", "action", name, "error", err)
+	}
+}
+
+// checkManifestParams returns a failure message if params miss a required property
+// or use a value outside a property's enum
+func checkManifestParams(schema *ActionSchema, params map[string]interface{}) string {
+	for _, name := range schema.Required {
+		if _, ok := params[name]; !ok {
+			return fmt.Sprintf("Missing required parameter %q", name)
+		}
+	}
+	for _, name := range sortedKeys(params) {
+		prop, ok := schema.Properties[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		enum, ok := prop["enum"].([]interface{})
+		if !ok {
+			continue
+		}
+		found := false
+		for _, v := range enum {
+			if sameJSONValue(v, params[name]) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			options := make([]string, len(enum))
+			for i, v := range enum {
+				options[i] = fmt.Sprint(v)
+			}
+			return fmt.Sprintf("Parameter %q must be one of: %s", name, strings.Join(options, ", "))
+		}
+	}
+	return ""
+}
+
+// sameJSONValue compares two decoded JSON values by their encoding, so the string
+// "1" does not match the number 1 and numbers match whatever type holds them
+func sameJSONValue(a, b interface{}) bool {
+	ab, errA := json.Marshal(a)
+	bb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ab, bb)
+}
+
+// ManifestLoader keeps a client's actions in sync with a manifest file
+type ManifestLoader struct {
+	client *Client
+	path   string
+	funcs  map[string]ActionFunc
+
+	mu       sync.Mutex
+	handlers map[string]*manifestHandler
+	modTime  time.Time
+}
+
+// LoadActionManifest loads a manifest file, binds its actions to funcs and registers
+// them. Call Reload or Watch on the returned loader to pick up changes to the file.
+func (c *Client) LoadActionManifest(path string, funcs map[string]ActionFunc) (*ManifestLoader, error) {
+	l := &ManifestLoader{
+		client:   c,
+		path:     path,
+		funcs:    funcs,
+		handlers: make(map[string]*manifestHandler),
+	}
+	if err := l.Reload(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Actions returns the names of the actions registered from the manifest
+func (l *ManifestLoader) Actions() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return sortedKeys(l.handlers)
+}
+
+// Reload reads the manifest again and updates the client: removed actions are
+// unregistered, new ones registered and changed ones refreshed. If the manifest
+// cannot be loaded, has lint errors or names a missing handler func, nothing changes.
+// Lint warnings are logged.
+// If the client cannot be updated, the loader only records the changes that were
+// applied, so the next Reload retries the rest.
+func (l *ManifestLoader) Reload() error {
+	info, err := os.Stat(l.path)
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+	m, err := LoadManifest(l.path)
+	if err != nil {
+		return err
+	}
+	bound, err := m.Bind(l.funcs)
+	if err != nil {
+		return fmt.Errorf("%s: %w", l.path, err)
+	}
+	for _, issue := range m.Warnings() {
+		l.client.logger.Warn("Action manifest lint warning", "path", l.path, "issue", issue.Error())
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var added []ActionHandler
+	var changed []*manifestHandler
+	seen := make(map[string]bool, len(bound))
+	for _, b := range bound {
+		nh := b.(*manifestHandler)
+		nh.logger = l.client.logger
+		def := nh.definition()
+		seen[def.Name] = true
+
+		h, ok := l.handlers[def.Name]
+		switch {
+		case !ok:
+			added = append(added, nh)
+		case !bytes.Equal(definitionSnapshot(h.definition()), definitionSnapshot(def)):
+			changed = append(changed, nh)
+		default:
+			// Same definition, so only the bound func may differ and Neuro needs no update
+			h.update(nh.binding())
+		}
+	}
+
+	var removed []string
+	for name := range l.handlers {
+		if !seen[name] {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+
+	if len(removed) > 0 || len(added) > 0 || len(changed) > 0 {
+		l.client.logger.Info("Reloading action manifest", "path", l.path,
+			"added", len(added), "changed", len(changed), "removed", len(removed))
+	}
+
+	// Update the client first and record each change only once it was applied
+	if err := l.client.UnregisterActions(removed); err != nil {
+		return err
+	}
+	for _, name := range removed {
+		delete(l.handlers, name)
+	}
+
+	if err := l.client.RegisterActions(added); err != nil {
+		return err
+	}
+	for _, h := range added {
+		l.handlers[h.GetName()] = h.(*manifestHandler)
+	}
+
+	if len(changed) > 0 {
+		// The registered handlers are updated in place so RefreshActions sends the
+		// new definitions, and restored if that fails
+		names := make([]string, len(changed))
+		previous := make([]manifestBinding, len(changed))
+		for i, nh := range changed {
+			h := l.handlers[nh.GetName()]
+			names[i] = nh.GetName()
+			previous[i] = h.binding()
+			h.update(nh.binding())
+		}
+		if err := l.client.RefreshActions(names...); err != nil {
+			for i, name := range names {
+				l.handlers[name].update(previous[i])
+			}
+			return err
+		}
+	}
+
+	l.modTime = info.ModTime()
+	return nil
+}
+
+// Watch polls the manifest file every interval and reloads it when it changes,
+// until ctx is cancelled. Reload errors are logged and the previous actions kept,
+// and a failed reload is retried on every poll until it succeeds.
+func (l *ManifestLoader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Modification time of the last failed reload, so each broken version is reported once
+	var failed time.Time
+	for {
+		select {
+		case <-ticker.C:
+			info, err := os.Stat(l.path)
+			if err != nil {
+				l.client.logger.Warn("Failed to check action manifest", "path", l.path, "error", err)
+				continue
+			}
+			// Reload only records the time once it succeeds
+			l.mu.Lock()
+			modified := !info.ModTime().Equal(l.modTime)
+			l.mu.Unlock()
+			if !modified {
+				continue
+			}
+			if err := l.Reload(); err != nil {
+				if !info.ModTime().Equal(failed) {
+					l.client.logger.Warn("Failed to reload action manifest", "path", l.path, "error", err)
+					failed = info.ModTime()
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
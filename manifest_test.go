@@ -0,0 +1,237 @@
+package neuro
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const testManifest = `
+actions:
+  - name: buy_item
+    handler: buy
+    description: Buy an item from the shop
+    schema:
+      type: object
+      properties:
+        item:
+          type: string
+          enum: [sword, shield]
+        slot:
+          type: integer
+          enum: [1, 2]
+      required: [item]
+`
+
+func TestParseManifest(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		failing bool
+	}{
+		{"yaml", testManifest, false},
+		{"json", `{"actions": [{"name": "jump", "description": "Jump"}]}`, false},
+		{"unknown field", `{"actions": [{"name": "jump", "descripton": "Jump"}]}`, true},
+		{"invalid yaml", "actions: [", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseManifest([]byte(tt.data))
+			if tt.failing != (err != nil) {
+				t.Errorf("ParseManifest() = %v, want failing %v", err, tt.failing)
+			}
+		})
+	}
+}
+
+func TestManifestActionValidate(t *testing.T) {
+	m, err := ParseManifest([]byte(testManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		success bool
+	}{
+		{"valid", `{"item": "sword"}`, true},
+		{"valid number enum", `{"item": "sword", "slot": 2}`, true},
+		{"missing required", `{"slot": 1}`, false},
+		{"outside enum", `{"item": "bow"}`, false},
+		{"number as string", `{"item": "sword", "slot": "1"}`, false},
+		{"not an object", `"sword"`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got json.RawMessage
+			handlers, err := m.Bind(map[string]ActionFunc{
+				"buy": func(data json.RawMessage) error {
+					got = data
+					return nil
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			state, result := handlers[0].Validate(json.RawMessage(tt.data))
+			if result.Successful != tt.success {
+				t.Fatalf("Validate() = %+v, want success %v", result, tt.success)
+			}
+			if got != nil {
+				t.Fatal("Validate() ran the action func")
+			}
+			if !tt.success {
+				return
+			}
+			handlers[0].Execute(state)
+			if string(got) != tt.data {
+				t.Errorf("func got %s, want %s", got, tt.data)
+			}
+		})
+	}
+}
+
+func TestManifestBindMissingFunc(t *testing.T) {
+	m, err := ParseManifest([]byte(testManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Bind(map[string]ActionFunc{"buy_item": nil}); err == nil {
+		t.Error("Bind() succeeded without the handler func")
+	}
+}
+
+func TestManifestBindWarnings(t *testing.T) {
+	m, err := ParseManifest([]byte(`{"actions": [{"name": "Jump", "description": "Jump",
+		"schema": {"type": "object", "properties": {"height": {"type": "integer", "multipleOf": 2}}}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Warnings do not stop the manifest from binding
+	noop := func(json.RawMessage) error { return nil }
+	if _, err := m.Bind(map[string]ActionFunc{"Jump": noop}); err != nil {
+		t.Fatalf("Bind() = %v with only warnings", err)
+	}
+	if got := len(m.Warnings()); got != 2 {
+		t.Errorf("Warnings() returned %d issues, want 2", got)
+	}
+}
+
+func TestManifestReload(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	path := filepath.Join(t.TempDir(), "actions.yaml")
+	write := func(data string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	noop := func(json.RawMessage) error { return nil }
+	funcs := map[string]ActionFunc{"jump": noop, "duck": noop, "run": noop}
+
+	write(`{"actions": [{"name": "jump", "description": "Jump"}, {"name": "duck", "description": "Duck"}]}`)
+	l, err := c.LoadActionManifest(path, funcs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+
+	// duck is removed, run is added and jump is changed
+	write(`{"actions": [{"name": "jump", "description": "Jump high"}, {"name": "run", "description": "Run"}]}`)
+	if err := l.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	for _, command := range []string{
+		CommandUnregisterActions, // duck
+		CommandRegisterActions,   // run
+		CommandUnregisterActions, // jump is refreshed
+		CommandRegisterActions,
+	} {
+		conn.expect(command)
+	}
+	if got := l.Actions(); !reflect.DeepEqual(got, []string{"jump", "run"}) {
+		t.Fatalf("Actions() = %v, want [jump run]", got)
+	}
+
+	// A failed reload keeps the actions that are still registered with the client
+	conn.conn.Close()
+	<-c.Errors()
+	write(`{"actions": [{"name": "duck", "description": "Duck"}]}`)
+	if err := l.Reload(); err == nil {
+		t.Fatal("Reload() succeeded without a connection")
+	}
+	if got := l.Actions(); !reflect.DeepEqual(got, []string{"jump", "run"}) {
+		t.Errorf("Actions() = %v after failing, want [jump run]", got)
+	}
+
+	// An invalid manifest changes nothing
+	write(`{"actions": [{"name": "duck", "description": ""}]}`)
+	if err := l.Reload(); err == nil {
+		t.Fatal("Reload() accepted a manifest that fails linting")
+	}
+	if got := l.Actions(); !reflect.DeepEqual(got, []string{"jump", "run"}) {
+		t.Errorf("Actions() = %v after a lint failure, want [jump run]", got)
+	}
+}
+
+func TestManifestWatchRetriesFailedReload(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	path := filepath.Join(t.TempDir(), "actions.yaml")
+	noop := func(json.RawMessage) error { return nil }
+	funcs := map[string]ActionFunc{"jump": noop, "duck": noop}
+	if err := os.WriteFile(path, []byte(`{"actions": [{"name": "jump", "description": "Jump"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	l, err := c.LoadActionManifest(path, funcs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+
+	// The change arrives while disconnected, so the first reloads fail
+	conn.conn.Close()
+	<-c.Errors()
+	changed := time.Now().Add(time.Second)
+	if err := os.WriteFile(path, []byte(`{"actions": [{"name": "jump", "description": "Jump"}, {"name": "duck", "description": "Duck"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, changed, changed); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go l.Watch(ctx, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+
+	// Reconnecting registers jump again and the retried reload registers duck, in either order
+	conn = connect(t, c, s)
+	waitFor(t, "reload to succeed", func() bool { return len(l.Actions()) == 2 })
+	registered := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		var data RegisterActionsData
+		if err := json.Unmarshal(conn.expect(CommandRegisterActions).Data, &data); err != nil {
+			t.Fatal(err)
+		}
+		for _, a := range data.Actions {
+			registered[a.Name] = true
+		}
+	}
+	if !registered["jump"] || !registered["duck"] {
+		t.Errorf("registered %v after reconnecting, want jump and duck", registered)
+	}
+}
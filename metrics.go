@@ -0,0 +1,311 @@
+package neuro
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics
+
+// Metrics receives measurements from the client.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// MessageSent counts an outbound message by command
+	MessageSent(command string)
+	// MessageReceived counts an inbound message by command
+	MessageReceived(command string)
+	// ActionReceived counts an incoming action by name
+	ActionReceived(name string)
+	// ActionValidated records the outcome and latency of Validate
+	ActionValidated(name string, success bool, latency time.Duration)
+	// ActionExecuted records the latency of Execute
+	ActionExecuted(name string, latency time.Duration)
+	// ForceAnswered records the time between an action force and the first action answering it
+	ForceAnswered(latency time.Duration)
+	// ForceEscalated counts an unanswered force re-issued with the given priority
+	ForceEscalated(priority Priority)
+	// Reconnected counts a successful connection after the first one
+	Reconnected()
+	// QueueDepth reports the current depth of a named queue
+	QueueDepth(queue string, depth int)
+}
+
+// nopMetrics discards all measurements
+type nopMetrics struct{}
+
+func (nopMetrics) MessageSent(string)                          {}
+func (nopMetrics) MessageReceived(string)                      {}
+func (nopMetrics) ActionReceived(string)                       {}
+func (nopMetrics) ActionValidated(string, bool, time.Duration) {}
+func (nopMetrics) ActionExecuted(string, time.Duration)        {}
+func (nopMetrics) ForceAnswered(time.Duration)                 {}
+func (nopMetrics) ForceEscalated(Priority)                     {}
+func (nopMetrics) Reconnected()                                {}
+func (nopMetrics) QueueDepth(string, int)                      {}
+
+// DefaultLatencyBuckets are the histogram upper bounds (in seconds) used by MemoryMetrics
+var DefaultLatencyBuckets = []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 30, 60}
+
+// MemoryMetrics is an in-memory Metrics implementation that can be
+// exposed in the Prometheus text format via its ServeHTTP method
+type MemoryMetrics struct {
+	mu sync.Mutex
+
+	buckets []float64
+
+	messagesSent     map[string]uint64
+	messagesReceived map[string]uint64
+	actionsReceived  map[string]uint64
+	validations      map[[2]string]uint64
+	validateLatency  map[string]*histogram
+	executeLatency   map[string]*histogram
+	forceLatency     *histogram
+	escalations      map[string]uint64
+	reconnects       uint64
+	queueDepth       map[string]int
+}
+
+// NewMemoryMetrics creates an empty in-memory metrics store
+func NewMemoryMetrics() *MemoryMetrics {
+	return &MemoryMetrics{
+		buckets:          DefaultLatencyBuckets,
+		messagesSent:     make(map[string]uint64),
+		messagesReceived: make(map[string]uint64),
+		actionsReceived:  make(map[string]uint64),
+		validations:      make(map[[2]string]uint64),
+		validateLatency:  make(map[string]*histogram),
+		executeLatency:   make(map[string]*histogram),
+		forceLatency:     newHistogram(DefaultLatencyBuckets),
+		escalations:      make(map[string]uint64),
+		queueDepth:       make(map[string]int),
+	}
+}
+
+// MessageSent implements Metrics
+func (m *MemoryMetrics) MessageSent(command string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messagesSent[command]++
+}
+
+// MessageReceived implements Metrics
+func (m *MemoryMetrics) MessageReceived(command string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messagesReceived[command]++
+}
+
+// ActionReceived implements Metrics
+func (m *MemoryMetrics) ActionReceived(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.actionsReceived[name]++
+}
+
+// ActionValidated implements Metrics
+func (m *MemoryMetrics) ActionValidated(name string, success bool, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := "failure"
+	if success {
+		result = "success"
+	}
+	m.validations[[2]string{name, result}]++
+
+	h, ok := m.validateLatency[name]
+	if !ok {
+		h = newHistogram(m.buckets)
+		m.validateLatency[name] = h
+	}
+	h.observe(latency.Seconds())
+}
+
+// ActionExecuted implements Metrics
+func (m *MemoryMetrics) ActionExecuted(name string, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.executeLatency[name]
+	if !ok {
+		h = newHistogram(m.buckets)
+		m.executeLatency[name] = h
+	}
+	h.observe(latency.Seconds())
+}
+
+// ForceAnswered implements Metrics
+func (m *MemoryMetrics) ForceAnswered(latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.forceLatency.observe(latency.Seconds())
+}
+
+// ForceEscalated implements Metrics
+func (m *MemoryMetrics) ForceEscalated(priority Priority) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.escalations[string(priority)]++
+}
+
+// Reconnected implements Metrics
+func (m *MemoryMetrics) Reconnected() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reconnects++
+}
+
+// QueueDepth implements Metrics
+func (m *MemoryMetrics) QueueDepth(queue string, depth int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.queueDepth[queue] = depth
+}
+
+// ValidationRatio returns the fraction of successful validations for an action.
+// It returns NaN if the action has never been validated.
+func (m *MemoryMetrics) ValidationRatio(name string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ok := m.validations[[2]string{name, "success"}]
+	failed := m.validations[[2]string{name, "failure"}]
+	if ok+failed == 0 {
+		return math.NaN()
+	}
+	return float64(ok) / float64(ok+failed)
+}
+
+// ServeHTTP writes all metrics in the Prometheus text exposition format
+func (m *MemoryMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes all metrics in the Prometheus text exposition format
+func (m *MemoryMetrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+
+	writeCounterVec(&b, "neuro_messages_sent_total", "Messages sent to Neuro by command.", "command", m.messagesSent)
+	writeCounterVec(&b, "neuro_messages_received_total", "Messages received from Neuro by command.", "command", m.messagesReceived)
+	writeCounterVec(&b, "neuro_actions_received_total", "Actions received from Neuro by name.", "action", m.actionsReceived)
+
+	b.WriteString("# HELP neuro_action_validations_total Action validations by name and result.\n")
+	b.WriteString("# TYPE neuro_action_validations_total counter\n")
+	keys := make([][2]string, 0, len(m.validations))
+	for k := range m.validations {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	for _, k := range keys {
+		fmt.Fprintf(&b, "neuro_action_validations_total{action=%s,result=%s} %d\n",
+			quoteLabel(k[0]), quoteLabel(k[1]), m.validations[k])
+	}
+
+	writeHistogramVec(&b, "neuro_action_validate_seconds", "Time spent in Validate.", "action", m.validateLatency)
+	writeHistogramVec(&b, "neuro_action_execute_seconds", "Time spent in Execute.", "action", m.executeLatency)
+
+	b.WriteString("# HELP neuro_force_response_seconds Time from an action force to the first action answering it.\n")
+	b.WriteString("# TYPE neuro_force_response_seconds histogram\n")
+	m.forceLatency.write(&b, "neuro_force_response_seconds", "")
+
+	writeCounterVec(&b, "neuro_force_escalations_total", "Unanswered forces re-issued by new priority.", "priority", m.escalations)
+
+	b.WriteString("# HELP neuro_reconnects_total Successful reconnections to Neuro.\n")
+	b.WriteString("# TYPE neuro_reconnects_total counter\n")
+	fmt.Fprintf(&b, "neuro_reconnects_total %d\n", m.reconnects)
+
+	b.WriteString("# HELP neuro_queue_depth Current depth of internal queues.\n")
+	b.WriteString("# TYPE neuro_queue_depth gauge\n")
+	for _, q := range sortedKeys(m.queueDepth) {
+		fmt.Fprintf(&b, "neuro_queue_depth{queue=%s} %d\n", quoteLabel(q), m.queueDepth[q])
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func writeCounterVec(b *strings.Builder, name, help, label string, values map[string]uint64) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+	for _, k := range sortedKeys(values) {
+		fmt.Fprintf(b, "%s{%s=%s} %d\n", name, label, quoteLabel(k), values[k])
+	}
+}
+
+func writeHistogramVec(b *strings.Builder, name, help, label string, values map[string]*histogram) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	for _, k := range sortedKeys(values) {
+		values[k].write(b, name, label+"="+quoteLabel(k))
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func quoteLabel(v string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(v) + `"`
+}
+
+// histogram is a cumulative Prometheus-style histogram
+type histogram struct {
+	bounds []float64
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{
+		bounds: bounds,
+		counts: make([]uint64, len(bounds)),
+	}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+func (h *histogram) write(b *strings.Builder, name, labels string) {
+	sep := ""
+	if labels != "" {
+		sep = ","
+	}
+	for i, bound := range h.bounds {
+		fmt.Fprintf(b, "%s_bucket{%s%sle=\"%s\"} %d\n",
+			name, labels, sep, strconv.FormatFloat(bound, 'g', -1, 64), h.counts[i])
+	}
+	fmt.Fprintf(b, "%s_bucket{%s%sle=\"+Inf\"} %d\n", name, labels, sep, h.count)
+	if labels != "" {
+		fmt.Fprintf(b, "%s_sum{%s} %g\n%s_count{%s} %d\n", name, labels, h.sum, name, labels, h.count)
+	} else {
+		fmt.Fprintf(b, "%s_sum %g\n%s_count %d\n", name, h.sum, name, h.count)
+	}
+}
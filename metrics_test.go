@@ -0,0 +1,149 @@
+package neuro
+
+import (
+	"math"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemoryMetricsExposition(t *testing.T) {
+	m := NewMemoryMetrics()
+	m.MessageSent(CommandStartup)
+	m.MessageSent(CommandStartup)
+	m.ActionReceived("buy")
+	m.ActionValidated("buy", true, 2*time.Millisecond)
+	m.ActionValidated("buy", false, 20*time.Millisecond)
+	m.ForceAnswered(3 * time.Second)
+	m.ForceEscalated(PriorityHigh)
+	m.Reconnected()
+	m.QueueDepth("forces", 2)
+
+	var b strings.Builder
+	if _, err := m.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+
+	for _, line := range []string{
+		"# HELP neuro_messages_sent_total Messages sent to Neuro by command.",
+		"# TYPE neuro_messages_sent_total counter",
+		`neuro_messages_sent_total{command="startup"} 2`,
+		`neuro_actions_received_total{action="buy"} 1`,
+		`neuro_action_validations_total{action="buy",result="failure"} 1`,
+		`neuro_action_validations_total{action="buy",result="success"} 1`,
+		"# TYPE neuro_action_validate_seconds histogram",
+		`neuro_action_validate_seconds_bucket{action="buy",le="0.001"} 0`,
+		`neuro_action_validate_seconds_bucket{action="buy",le="0.005"} 1`,
+		`neuro_action_validate_seconds_bucket{action="buy",le="0.05"} 2`,
+		`neuro_action_validate_seconds_bucket{action="buy",le="+Inf"} 2`,
+		`neuro_action_validate_seconds_count{action="buy"} 2`,
+		`neuro_force_response_seconds_bucket{le="1"} 0`,
+		`neuro_force_response_seconds_bucket{le="5"} 1`,
+		"neuro_force_response_seconds_sum 3",
+		"neuro_force_response_seconds_count 1",
+		`neuro_force_escalations_total{priority="high"} 1`,
+		"neuro_reconnects_total 1",
+		`neuro_queue_depth{queue="forces"} 2`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("exposition has no line %q:\n%s", line, out)
+		}
+	}
+
+	// Every sample line is a metric name, optional labels and a value
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if fields := strings.Fields(line); len(fields) != 2 || !strings.HasPrefix(fields[0], "neuro_") {
+			t.Errorf("malformed sample line %q", line)
+		}
+	}
+}
+
+func TestMemoryMetricsLabelEscaping(t *testing.T) {
+	tests := []struct {
+		name   string
+		action string
+		want   string
+	}{
+		{"plain", "buy", `neuro_actions_received_total{action="buy"} 1`},
+		{"quote", `say "hi"`, `neuro_actions_received_total{action="say \"hi\""} 1`},
+		{"backslash", `path\to`, `neuro_actions_received_total{action="path\\to"} 1`},
+		{"newline", "two\nlines", `neuro_actions_received_total{action="two\nlines"} 1`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemoryMetrics()
+			m.ActionReceived(tt.action)
+
+			var b strings.Builder
+			m.WriteTo(&b)
+			if !strings.Contains(b.String(), tt.want+"\n") {
+				t.Errorf("exposition has no line %q:\n%s", tt.want, b.String())
+			}
+		})
+	}
+}
+
+func TestMemoryMetricsServeHTTP(t *testing.T) {
+	m := NewMemoryMetrics()
+	m.Reconnected()
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if !strings.Contains(rec.Body.String(), "neuro_reconnects_total 1\n") {
+		t.Errorf("body does not count the reconnect:\n%s", rec.Body.String())
+	}
+}
+
+func TestClientMetrics(t *testing.T) {
+	m := NewMemoryMetrics()
+	s := newTestServer(t)
+	c := newTestClient(t, s, func(config *ClientConfig) { config.Metrics = m })
+	conn := connect(t, c, s)
+
+	if got := m.ValidationRatio("buy"); !math.IsNaN(got) {
+		t.Errorf("ValidationRatio() = %v before any action, want NaN", got)
+	}
+
+	if err := c.RegisterAction(&testAction{name: "buy"}); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+	conn.sendAction("1", "buy", "")
+	conn.expectResult()
+	conn.sendAction("2", "unknown", "")
+	conn.expectResult()
+
+	if got := m.ValidationRatio("buy"); got != 1 {
+		t.Errorf("ValidationRatio() = %v, want 1", got)
+	}
+
+	// Execute runs after the result is sent
+	exposition := func() string {
+		var b strings.Builder
+		m.WriteTo(&b)
+		return b.String()
+	}
+	executed := `neuro_action_execute_seconds_count{action="buy"} 1`
+	waitFor(t, "execute latency", func() bool { return strings.Contains(exposition(), executed+"\n") })
+
+	out := exposition()
+	for _, line := range []string{
+		`neuro_messages_sent_total{command="startup"} 1`,
+		`neuro_messages_sent_total{command="actions/register"} 1`,
+		`neuro_messages_received_total{command="action"} 2`,
+		`neuro_actions_received_total{action="buy"} 1`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("exposition has no line %q:\n%s", line, out)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package neuro
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+)
+
+// Modules
+
+// Module bundles a reusable piece of an integration, such as a shop or dialogue
+// system: its actions and the lifecycle hooks the client calls for it.
+// Embed BaseModule to only implement the hooks you need.
+type Module interface {
+	// Actions returns the handlers registered for the module on every connection
+	Actions() []ActionHandler
+	// OnConnect is called once the client has connected and registered the module's actions
+	OnConnect(client *Client)
+	// OnDisconnect is called when the connection to Neuro is lost
+	OnDisconnect()
+	// OnReregister is called after Neuro asked for all actions to be registered again
+	OnReregister()
+	// OnShutdown is called once, when Neuro requests a shutdown or the client is closed
+	OnShutdown()
+}
+
+// BaseModule provides no-op Module hooks for embedding
+type BaseModule struct{}
+
+// Actions implements Module
+func (BaseModule) Actions() []ActionHandler { return nil }
+
+// OnConnect implements Module
+func (BaseModule) OnConnect(*Client) {}
+
+// OnDisconnect implements Module
+func (BaseModule) OnDisconnect() {}
+
+// OnReregister implements Module
+func (BaseModule) OnReregister() {}
+
+// OnShutdown implements Module
+func (BaseModule) OnShutdown() {}
+
+// moduleSet holds the modules added to a client
+type moduleSet struct {
+	mu       sync.Mutex
+	modules  []Module
+	shutdown sync.Once
+}
+
+// AddModule adds a module to the client. If the client is already connected,
+// the module's actions are registered and OnConnect is called right away; if
+// that registration fails, the module is not added.
+func (c *Client) AddModule(m Module) error {
+	c.modules.mu.Lock()
+	for _, existing := range c.modules.modules {
+		if sameValue(existing, m) {
+			c.modules.mu.Unlock()
+			return errors.New("module already added")
+		}
+	}
+	c.modules.modules = append(c.modules.modules, m)
+	c.modules.mu.Unlock()
+
+	c.connMu.RLock()
+	connected := c.connected
+	c.connMu.RUnlock()
+
+	if !connected {
+		return nil
+	}
+	if err := c.RegisterActions(m.Actions()); err != nil {
+		c.dropModule(m)
+		return err
+	}
+	m.OnConnect(c)
+	return nil
+}
+
+// RemoveModule unregisters a module's actions and stops calling its hooks
+func (c *Client) RemoveModule(m Module) error {
+	if !c.dropModule(m) {
+		return errors.New("module not added")
+	}
+
+	actions := m.Actions()
+	names := make([]string, len(actions))
+	for i, a := range actions {
+		names[i] = a.GetName()
+	}
+	return c.UnregisterActions(names)
+}
+
+// dropModule removes a module from the list and reports whether it was there
+func (c *Client) dropModule(m Module) bool {
+	c.modules.mu.Lock()
+	defer c.modules.mu.Unlock()
+
+	for i, existing := range c.modules.modules {
+		if sameValue(existing, m) {
+			c.modules.modules = append(c.modules.modules[:i], c.modules.modules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// moduleList returns a snapshot of the modules so hooks run without the lock held
+func (c *Client) moduleList() []Module {
+	c.modules.mu.Lock()
+	defer c.modules.mu.Unlock()
+	return append([]Module(nil), c.modules.modules...)
+}
+
+// connectModules registers every module's actions and calls OnConnect
+func (c *Client) connectModules() {
+	modules := c.moduleList()
+	for _, m := range modules {
+		if err := c.registerUnsent(m.Actions()); err != nil {
+			c.logger.Error("Failed to register module actions", "error", err)
+		}
+	}
+	for _, m := range modules {
+		m.OnConnect(c)
+	}
+}
+
+// registerUnsent registers handlers, skipping the send for those whose definition
+// Neuro already has, such as module actions that were sent again on reconnect
+func (c *Client) registerUnsent(handlers []ActionHandler) error {
+	c.actionsMu.Lock()
+	defer c.actionsMu.Unlock()
+
+	var unsent []ActionHandler
+	for _, h := range handlers {
+		def := ActionDefinition{Name: h.GetName(), Description: h.GetDescription(), Schema: h.GetSchema()}
+		if sent, ok := c.sent[def.Name]; ok && def.Name != "" && isAvailable(h) && bytes.Equal(sent, definitionSnapshot(def)) {
+			c.actions[def.Name] = h
+			continue
+		}
+		unsent = append(unsent, h)
+	}
+	if len(unsent) == 0 {
+		return nil
+	}
+	return c.registerActionsLocked(unsent)
+}
+
+// disconnectModules calls OnDisconnect on every module
+func (c *Client) disconnectModules() {
+	for _, m := range c.moduleList() {
+		m.OnDisconnect()
+	}
+}
+
+// reregisterModules calls OnReregister on every module
+func (c *Client) reregisterModules() {
+	for _, m := range c.moduleList() {
+		m.OnReregister()
+	}
+}
+
+// shutdownModules calls OnShutdown on every module, at most once per client
+func (c *Client) shutdownModules() {
+	c.modules.shutdown.Do(func() {
+		for _, m := range c.moduleList() {
+			m.OnShutdown()
+		}
+	})
+}
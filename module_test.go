@@ -0,0 +1,152 @@
+package neuro
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// testModule records the hooks the client calls
+type testModule struct {
+	BaseModule
+	actions []ActionHandler
+
+	mu     sync.Mutex
+	events []string
+}
+
+func (m *testModule) Actions() []ActionHandler { return m.actions }
+func (m *testModule) OnConnect(*Client)        { m.record("connect") }
+func (m *testModule) OnDisconnect()            { m.record("disconnect") }
+func (m *testModule) OnReregister()            { m.record("reregister") }
+
+func (m *testModule) record(event string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, event)
+}
+
+func (m *testModule) history() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.events...)
+}
+
+// registeredNames reads the next message as a registration and returns its action names
+func registeredNames(t *testing.T, conn *testConn) []string {
+	t.Helper()
+
+	var data RegisterActionsData
+	if err := json.Unmarshal(conn.expect(CommandRegisterActions).Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(data.Actions))
+	for i, a := range data.Actions {
+		names[i] = a.Name
+	}
+	return names
+}
+
+func TestModuleLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	m := &testModule{actions: []ActionHandler{&testAction{name: "buy"}}}
+	if err := c.AddModule(m); err != nil {
+		t.Fatal(err)
+	}
+
+	// Connecting registers the module's actions before OnConnect
+	conn := connect(t, c, s)
+	if names := registeredNames(t, conn); !reflect.DeepEqual(names, []string{"buy"}) {
+		t.Errorf("registered %v on connect, want [buy]", names)
+	}
+	if got := m.history(); !reflect.DeepEqual(got, []string{"connect"}) {
+		t.Fatalf("hooks = %v after connecting, want [connect]", got)
+	}
+
+	conn.conn.Close()
+	<-c.Errors()
+	if got := m.history(); !reflect.DeepEqual(got, []string{"connect", "disconnect"}) {
+		t.Fatalf("hooks = %v after disconnecting, want [connect disconnect]", got)
+	}
+
+	// Reconnecting registers the actions once more, then calls OnConnect again
+	conn = connect(t, c, s)
+	if names := registeredNames(t, conn); !reflect.DeepEqual(names, []string{"buy"}) {
+		t.Errorf("registered %v on reconnect, want [buy]", names)
+	}
+	conn.send(CommandReregisterAll, nil)
+	if names := registeredNames(t, conn); !reflect.DeepEqual(names, []string{"buy"}) {
+		t.Errorf("registered %v when asked to reregister, want [buy]", names)
+	}
+	waitFor(t, "OnReregister", func() bool { return len(m.history()) == 4 })
+	if got, want := m.history(), []string{"connect", "disconnect", "connect", "reregister"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("hooks = %v, want %v", got, want)
+	}
+}
+
+func TestAddAndRemoveModule(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	m := &testModule{actions: []ActionHandler{&testAction{name: "buy"}, &testAction{name: "sell"}}}
+	if err := c.AddModule(m); err != nil {
+		t.Fatal(err)
+	}
+	if names := registeredNames(t, conn); !reflect.DeepEqual(names, []string{"buy", "sell"}) {
+		t.Errorf("registered %v, want [buy sell]", names)
+	}
+	if got := m.history(); !reflect.DeepEqual(got, []string{"connect"}) {
+		t.Errorf("hooks = %v, want OnConnect right away", got)
+	}
+	if err := c.AddModule(m); err == nil {
+		t.Error("adding a module twice succeeded")
+	}
+
+	if err := c.RemoveModule(m); err != nil {
+		t.Fatal(err)
+	}
+	var data UnregisterActionsData
+	if err := json.Unmarshal(conn.expect(CommandUnregisterActions).Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(data.ActionNames, []string{"buy", "sell"}) {
+		t.Errorf("unregistered %v, want [buy sell]", data.ActionNames)
+	}
+	if err := c.RemoveModule(m); err == nil {
+		t.Error("removing a module twice succeeded")
+	}
+
+	// A removed module's hooks are no longer called
+	conn.conn.Close()
+	<-c.Errors()
+	if got := m.history(); !reflect.DeepEqual(got, []string{"connect"}) {
+		t.Errorf("hooks = %v after removing, want [connect]", got)
+	}
+}
+
+func TestAddModuleFailure(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	bad := &testModule{actions: []ActionHandler{&testAction{name: ""}}}
+	if err := c.AddModule(bad); err == nil {
+		t.Fatal("AddModule() succeeded with an invalid action")
+	}
+	if got := bad.history(); len(got) != 0 {
+		t.Errorf("hooks = %v for a module that was not added", got)
+	}
+	if len(c.moduleList()) != 0 {
+		t.Fatal("a module whose registration failed was kept")
+	}
+
+	// The module can be added once its actions are fixed
+	bad.actions = []ActionHandler{&testAction{name: "buy"}}
+	if err := c.AddModule(bad); err != nil {
+		t.Fatalf("AddModule() after fixing = %v", err)
+	}
+	conn.expect(CommandRegisterActions)
+}
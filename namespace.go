@@ -0,0 +1,192 @@
+package neuro
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// Namespaces
+
+// ErrActionExists is returned when a namespaced action would replace an action
+// registered by someone else
+var ErrActionExists = errors.New("action already registered")
+
+// namespaceNamePattern matches names that give valid action name prefixes
+var namespaceNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Namespace is a scoped view of a client's actions for one game subsystem.
+// Actions registered through it are prefixed with the namespace name, so
+// "use" in the "inventory" namespace is registered as "inventory_use".
+type Namespace struct {
+	client *Client
+	name   string
+	// err is set for an invalid name and returned by every registration
+	err error
+
+	mu       sync.Mutex
+	handlers map[string]ActionHandler
+}
+
+// Namespace returns a registry whose action names are prefixed with name.
+// The name must be non-empty and only contain letters, digits, underscores and
+// dashes; otherwise registering through the namespace fails.
+func (c *Client) Namespace(name string) *Namespace {
+	n := &Namespace{
+		client:   c,
+		name:     name,
+		handlers: make(map[string]ActionHandler),
+	}
+	if !namespaceNamePattern.MatchString(name) {
+		n.err = fmt.Errorf("invalid namespace name %q", name)
+	}
+	return n
+}
+
+// Name returns the full name of an action in this namespace
+func (n *Namespace) Name(action string) string {
+	return n.name + "_" + action
+}
+
+// RegisterAction registers a single action in this namespace
+func (n *Namespace) RegisterAction(handler ActionHandler) error {
+	return n.RegisterActions([]ActionHandler{handler})
+}
+
+// RegisterActions registers actions under their prefixed names. It fails without
+// registering anything if a name is already used by an action that this
+// namespace did not register. Re-registering the namespace's own actions replaces them.
+func (n *Namespace) RegisterActions(handlers []ActionHandler) error {
+	if n.err != nil {
+		return n.err
+	}
+	if len(handlers) == 0 {
+		return nil
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	wrapped := make([]ActionHandler, len(handlers))
+	seen := make(map[string]bool, len(handlers))
+	for i, h := range handlers {
+		if h.GetName() == "" {
+			return errors.New("action name cannot be empty")
+		}
+		name := n.Name(h.GetName())
+		if seen[name] {
+			return fmt.Errorf("action %q is registered twice in namespace %q", name, n.name)
+		}
+		seen[name] = true
+		wrapped[i] = &namespacedHandler{ActionHandler: h, name: name}
+	}
+
+	c := n.client
+	c.actionsMu.Lock()
+	for name := range seen {
+		if existing, ok := c.actions[name]; ok && existing != n.handlers[name] {
+			c.actionsMu.Unlock()
+			return fmt.Errorf("%w: %q", ErrActionExists, name)
+		}
+	}
+	err := c.registerActionsLocked(wrapped)
+	if err == nil {
+		for _, h := range wrapped {
+			n.handlers[h.GetName()] = h
+		}
+	}
+	c.actionsMu.Unlock()
+
+	return err
+}
+
+// UnregisterActions unregisters actions of this namespace by their unprefixed names
+func (n *Namespace) UnregisterActions(actions ...string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	names := make([]string, 0, len(actions))
+	for _, a := range actions {
+		names = append(names, n.Name(a))
+	}
+	return n.unregister(names)
+}
+
+// UnregisterAll unregisters every action registered through this namespace
+func (n *Namespace) UnregisterAll() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	names := make([]string, 0, len(n.handlers))
+	for name := range n.handlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return n.unregister(names)
+}
+
+// unregister removes the given full names that this namespace still owns.
+// Must be called with n.mu held.
+func (n *Namespace) unregister(names []string) error {
+	c := n.client
+
+	c.actionsMu.RLock()
+	var owned []string
+	for _, name := range names {
+		h, ok := n.handlers[name]
+		if !ok {
+			continue
+		}
+		if current, ok := c.actions[name]; ok && current == h {
+			owned = append(owned, name)
+		}
+	}
+	c.actionsMu.RUnlock()
+
+	if err := c.UnregisterActions(owned); err != nil {
+		return err
+	}
+	for _, name := range names {
+		delete(n.handlers, name)
+	}
+	return nil
+}
+
+// Actions returns the full names of the actions registered through this namespace
+func (n *Namespace) Actions() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return sortedKeys(n.handlers)
+}
+
+// ForceActions forces actions of this namespace by their unprefixed names
+func (n *Namespace) ForceActions(query string, actions []string, opts ...ForceOption) error {
+	names := make([]string, len(actions))
+	for i, a := range actions {
+		names[i] = n.Name(a)
+	}
+	return n.client.ForceActions(query, names, opts...)
+}
+
+// namespacedHandler registers a handler under its prefixed name
+type namespacedHandler struct {
+	ActionHandler
+	name string
+}
+
+func (h *namespacedHandler) GetName() string {
+	return h.name
+}
+
+// IsAvailable forwards to the wrapped handler, which is always available if it has no predicate
+func (h *namespacedHandler) IsAvailable() bool {
+	return isAvailable(h.ActionHandler)
+}
+
+// HasDynamicSchema forwards to the wrapped handler
+func (h *namespacedHandler) HasDynamicSchema() bool {
+	d, ok := h.ActionHandler.(DynamicSchemaHandler)
+	return ok && d.HasDynamicSchema()
+}
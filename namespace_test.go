@@ -0,0 +1,189 @@
+package neuro
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNamespaceRegisterOwnership(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(c *Client, inv *Namespace) error
+		actions []string
+		wantErr error // checked with errors.Is if set
+		failing bool
+	}{
+		{"new action", func(c *Client, inv *Namespace) error { return nil }, []string{"use"}, nil, false},
+		{"own action again", func(c *Client, inv *Namespace) error {
+			return inv.RegisterAction(&testAction{name: "use"})
+		}, []string{"use"}, nil, false},
+		{"name taken by a plain action", func(c *Client, inv *Namespace) error {
+			return c.RegisterAction(&testAction{name: "inventory_use"})
+		}, []string{"use"}, ErrActionExists, true},
+		{"name taken by another namespace", func(c *Client, inv *Namespace) error {
+			return c.Namespace("inventory").RegisterAction(&testAction{name: "use"})
+		}, []string{"use"}, ErrActionExists, true},
+		{"own action replaced by a plain action", func(c *Client, inv *Namespace) error {
+			if err := inv.RegisterAction(&testAction{name: "use"}); err != nil {
+				return err
+			}
+			return c.RegisterAction(&testAction{name: "inventory_use"})
+		}, []string{"use"}, ErrActionExists, true},
+		{"same action twice", func(c *Client, inv *Namespace) error { return nil }, []string{"use", "use"}, nil, true},
+		{"empty name", func(c *Client, inv *Namespace) error { return nil }, []string{""}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			c := newTestClient(t, s, nil)
+			connect(t, c, s)
+			inv := c.Namespace("inventory")
+			if err := tt.setup(c, inv); err != nil {
+				t.Fatal(err)
+			}
+			before := inv.Actions()
+
+			handlers := make([]ActionHandler, len(tt.actions))
+			for i, name := range tt.actions {
+				handlers[i] = &testAction{name: name}
+			}
+			err := inv.RegisterActions(handlers)
+
+			if !tt.failing {
+				if err != nil {
+					t.Fatalf("RegisterActions() = %v", err)
+				}
+				if got := inv.Actions(); !reflect.DeepEqual(got, []string{"inventory_use"}) {
+					t.Errorf("Actions() = %v, want [inventory_use]", got)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("RegisterActions() succeeded, want error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("RegisterActions() = %v, want %v", err, tt.wantErr)
+			}
+			if got := inv.Actions(); !reflect.DeepEqual(got, before) {
+				t.Errorf("Actions() = %v after failing, want %v", got, before)
+			}
+		})
+	}
+}
+
+func TestNamespaceRegisterWhileDisconnected(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	inv := c.Namespace("inventory")
+
+	// A failed registration must not leave the name owned by a handler Neuro never got
+	if err := inv.RegisterAction(&testAction{name: "use"}); err == nil {
+		t.Fatal("RegisterAction() succeeded without a connection")
+	}
+	if got := inv.Actions(); len(got) != 0 {
+		t.Fatalf("Actions() = %v after failing, want none", got)
+	}
+	if _, err := c.ExportActions(); err != nil {
+		t.Fatal(err)
+	}
+
+	conn := connect(t, c, s)
+	if err := inv.RegisterAction(&testAction{name: "use"}); err != nil {
+		t.Fatalf("RegisterAction() after connecting = %v", err)
+	}
+	var data RegisterActionsData
+	if err := json.Unmarshal(conn.expect(CommandRegisterActions).Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Actions) != 1 || data.Actions[0].Name != "inventory_use" {
+		t.Errorf("registered %+v, want inventory_use", data.Actions)
+	}
+}
+
+func TestNamespaceUnregister(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	inv := c.Namespace("inventory")
+	if err := inv.RegisterActions([]ActionHandler{&testAction{name: "use"}, &testAction{name: "drop"}}); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+
+	// inventory_drop now belongs to someone else, so the namespace leaves it alone
+	if err := c.RegisterAction(&testAction{name: "inventory_drop"}); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+
+	if err := inv.UnregisterAll(); err != nil {
+		t.Fatal(err)
+	}
+	var data UnregisterActionsData
+	if err := json.Unmarshal(conn.expect(CommandUnregisterActions).Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(data.ActionNames, []string{"inventory_use"}) {
+		t.Errorf("unregistered %v, want [inventory_use]", data.ActionNames)
+	}
+	if got := inv.Actions(); len(got) != 0 {
+		t.Errorf("Actions() = %v, want none", got)
+	}
+	c.actionsMu.RLock()
+	_, kept := c.actions["inventory_drop"]
+	c.actionsMu.RUnlock()
+	if !kept {
+		t.Error("the plain inventory_drop action was unregistered")
+	}
+}
+
+func TestNamespaceUnregisterWhileDisconnected(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	inv := c.Namespace("inventory")
+	if err := inv.RegisterAction(&testAction{name: "use"}); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+
+	conn.conn.Close()
+	<-c.Errors()
+
+	// The action is still registered, so the namespace keeps owning it
+	if err := inv.UnregisterAll(); err == nil {
+		t.Fatal("UnregisterAll() succeeded without a connection")
+	}
+	if got := inv.Actions(); !reflect.DeepEqual(got, []string{"inventory_use"}) {
+		t.Fatalf("Actions() = %v after failing, want [inventory_use]", got)
+	}
+
+	conn = connect(t, c, s)
+	conn.expect(CommandRegisterActions)
+	if err := inv.UnregisterAll(); err != nil {
+		t.Fatalf("UnregisterAll() after reconnecting = %v", err)
+	}
+	conn.expect(CommandUnregisterActions)
+}
+
+func TestNamespaceInvalidName(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	connect(t, c, s)
+
+	for _, name := range []string{"", "my inventory", "inventory.items"} {
+		if err := c.Namespace(name).RegisterAction(&testAction{name: "use"}); err == nil {
+			t.Errorf("Namespace(%q).RegisterAction() succeeded, want invalid name", name)
+		}
+	}
+	c.actionsMu.RLock()
+	defer c.actionsMu.RUnlock()
+	if len(c.actions) != 0 {
+		t.Errorf("registered %d actions through invalid namespaces", len(c.actions))
+	}
+}
@@ -2,12 +2,15 @@
 package neuro
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/url"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -98,7 +101,39 @@ type ActionHandler interface {
 type ClientConfig struct {
 	Game         string
 	WebsocketURL string
-	Logger       *log.Logger
+	// Logger is a legacy standard library logger. It is only used when
+	// LogHandler is nil, in which case records are written to its output.
+	Logger *log.Logger
+	// LogHandler receives structured log records from the client.
+	// Payloads are logged at debug, lifecycle events at info and
+	// unknown commands or actions at warn.
+	LogHandler slog.Handler
+	// RedactPayload is applied to every payload before it is logged (optional)
+	RedactPayload PayloadRedactor
+	// Metrics receives client measurements (optional)
+	Metrics Metrics
+	// Tracer creates spans for forces and action attempts (optional)
+	Tracer Tracer
+	// Recorder captures every inbound and outbound message (optional)
+	Recorder *Recorder
+	// Conformance checks outbound messages against the protocol before they are sent
+	Conformance ConformanceMode
+	// ActionResultTimeout is how long an action may go without a result before it is
+	// failed automatically. Zero uses DefaultActionResultTimeout, negative disables it.
+	ActionResultTimeout time.Duration
+	// History records every incoming action and how it was handled (optional)
+	History ActionHistory
+	// RefreshBeforeForce refreshes forced DynamicSchemaHandlers before each action force
+	RefreshBeforeForce bool
+	// AvailabilityInterval calls Tick periodically to update which
+	// AvailabilityHandlers are registered with Neuro (0 disables it)
+	AvailabilityInterval time.Duration
+	// QueueForces makes ForceActions queue forces by priority while another force
+	// is unanswered, instead of superseding it (see QueueForce)
+	QueueForces bool
+	// QueueTimeout is how long an unanswered force may hold back queued forces before
+	// the next one is sent. Zero uses DefaultQueueTimeout, negative disables it.
+	QueueTimeout time.Duration
 }
 
 // Client
@@ -108,9 +143,12 @@ type Client struct {
 	config ClientConfig
 	conn   *websocket.Conn
 	connMu sync.RWMutex
+	// writeMu serializes writes, which gorilla/websocket does not allow concurrently
+	writeMu sync.Mutex
 
-	// Registered actions
+	// Registered actions and the definitions last sent for them
 	actions   map[string]ActionHandler
+	sent      map[string][]byte
 	actionsMu sync.RWMutex
 
 	// Channels
@@ -119,10 +157,55 @@ type Client struct {
 	closeChan  chan struct{}
 
 	// State
-	connected bool
-	closed    bool
+	connected    bool
+	closed       bool
+	hasConnected bool
 
-	logger *log.Logger
+	// Most recent action force, used for latency and tracing
+	lastForce *activeForce
+	forceMu   sync.Mutex
+
+	// Forces waiting for the active force to be answered
+	forceQueue forceQueue
+
+	// Number of actions currently being validated or executed
+	actionsInFlight int32
+
+	// Actions awaiting a result
+	pending *pendingTable
+
+	// Scene definitions and the active scene
+	scenes *sceneState
+
+	// Modules driven by the client's lifecycle
+	modules moduleSet
+
+	// Context templates by event type and the state snapshot used for forces
+	templates contextTemplates
+	state     stateConfig
+
+	logger      *slog.Logger
+	metrics     Metrics
+	tracer      Tracer
+	conformance *ProtocolChecker
+}
+
+// activeForce tracks an action force until an action answers it
+type activeForce struct {
+	query       string
+	actionNames []string
+	priority    Priority
+	names       map[string]bool
+	at          time.Time
+	answered    bool
+	ctx         context.Context
+	span        Span
+	// waiter receives the answer for ForceAndWait (nil otherwise)
+	waiter *forceWaiter
+	// queueTimer expires the force once forces are queued behind it (nil until then)
+	queueTimer *time.Timer
+	// escalatedTo is the force that re-issued this one, which an answer in flight completes instead
+	escalatedTo *activeForce
 }
 
 // NewClient creates a new Neuro SDK client
@@ -135,16 +218,29 @@ func NewClient(config ClientConfig) (*Client, error) {
 	}
 
 	c := &Client{
-		config:     config,
-		actions:    make(map[string]ActionHandler),
-		actionChan: make(chan IncomingAction, 16),
-		errChan:    make(chan error, 8),
-		closeChan:  make(chan struct{}),
-		logger:     config.Logger,
+		config:      config,
+		actions:     make(map[string]ActionHandler),
+		sent:        make(map[string][]byte),
+		actionChan:  make(chan IncomingAction, 16),
+		errChan:     make(chan error, 8),
+		closeChan:   make(chan struct{}),
+		logger:      newLogger(config),
+		metrics:     config.Metrics,
+		tracer:      config.Tracer,
+		conformance: NewProtocolChecker(),
+		pending:     newPendingTable(),
+		scenes:      newSceneState(),
+	}
+
+	if c.metrics == nil {
+		c.metrics = nopMetrics{}
+	}
+	if c.tracer == nil {
+		c.tracer = nopTracer{}
 	}
 
-	if c.logger == nil {
-		c.logger = log.Default()
+	if config.AvailabilityInterval > 0 {
+		go c.tickLoop(config.AvailabilityInterval)
 	}
 
 	return c, nil
@@ -155,18 +251,21 @@ func (c *Client) Connect() error {
 	c.connMu.Lock()
 
 	if c.closed {
+		c.connMu.Unlock()
 		return errors.New("client is closed")
 	}
 	if c.connected {
+		c.connMu.Unlock()
 		return errors.New("already connected")
 	}
 
 	u, err := url.Parse(c.config.WebsocketURL)
 	if err != nil {
+		c.connMu.Unlock()
 		return fmt.Errorf("invalid websocket URL: %w", err)
 	}
 
-	c.logger.Printf("Connecting to %s...", u.String())
+	c.logger.Info("Connecting", "url", u.String())
 
 	// Set connection timeout to prevent hanging
 	dialer := *websocket.DefaultDialer
@@ -174,58 +273,80 @@ func (c *Client) Connect() error {
 
 	conn, resp, err := dialer.Dial(u.String(), nil)
 	if err != nil {
+		c.connMu.Unlock()
 		if resp != nil {
 			return fmt.Errorf("failed to connect (HTTP %d): %w", resp.StatusCode, err)
 		}
 		return fmt.Errorf("failed to connect: %w", err)
 	}
 
-	c.logger.Printf("WebSocket connection established")
+	c.logger.Info("WebSocket connection established")
 
 	c.conn = conn
 	c.connected = true
+	// Actions from a previous connection can no longer be answered
+	c.pending.reset()
+	c.conformance.Reset()
+	if c.hasConnected {
+		c.metrics.Reconnected()
+	}
+	c.hasConnected = true
 
 	// Start reader goroutine
-	go c.readLoop()
+	go c.readLoop(conn)
 
 	// CRITICAL: Unlock BEFORE calling Startup() to avoid deadlock
 	// Startup() calls send() which needs to acquire a read lock
 	c.connMu.Unlock()
-	
+
 	// Send startup message
 	if err := c.Startup(); err != nil {
-		c.logger.Printf("Failed to send startup message: %v", err)
+		c.logger.Error("Failed to send startup message", "error", err)
 		return fmt.Errorf("failed to send startup: %w", err)
 	}
 
-	c.logger.Printf("Startup message sent successfully")
+	c.logger.Info("Startup message sent")
+
+	// Startup cleared Neuro's actions, so send the ones registered or declared so far
+	if err := c.sendRegisteredActions(); err != nil {
+		c.logger.Error("Failed to register actions", "error", err)
+	}
+
+	c.connectModules()
 
 	return nil
 }
 
 // Message Reading
 
-func (c *Client) readLoop() {
-	c.logger.Printf("Read loop started")
+func (c *Client) readLoop(conn *websocket.Conn) {
+	c.logger.Debug("Read loop started")
 	for {
 		select {
 		case <-c.closeChan:
-			c.logger.Printf("Read loop stopping (close signal)")
+			c.logger.Debug("Read loop stopping (close signal)")
 			return
 		default:
-			_, msgBytes, err := c.conn.ReadMessage()
+			_, msgBytes, err := conn.ReadMessage()
 			if err != nil {
-				if !c.closed {
-					c.logger.Printf("Read error: %v", err)
+				c.connMu.Lock()
+				closed := c.closed
+				// Allow Connect to be called again to reconnect
+				if c.conn == conn {
+					c.connected = false
+				}
+				c.connMu.Unlock()
+
+				if !closed {
+					c.logger.Error("Read error", "error", err)
+					c.disconnectModules()
 					c.errChan <- fmt.Errorf("read error: %w", err)
 				}
 				return
 			}
 
-			c.logger.Printf("Received message: %s", string(msgBytes))
-
 			if err := c.handleMessage(msgBytes); err != nil {
-				c.logger.Printf("Error handling message: %v", err)
+				c.logger.Warn("Error handling message", "error", err)
 			}
 		}
 	}
@@ -237,70 +358,252 @@ func (c *Client) handleMessage(msgBytes []byte) error {
 		return fmt.Errorf("failed to parse message: %w", err)
 	}
 
-	c.logger.Printf("Received command: %s", msg.Command)
+	c.logPayload("Received message", msg.Command, msgBytes)
+	c.metrics.MessageReceived(msg.Command)
+	c.record(DirectionInbound, msg)
+	c.observeInbound(msg)
 
 	switch msg.Command {
-	case "action":
+	case CommandAction:
 		var action IncomingAction
 		if err := json.Unmarshal(msg.Data, &action); err != nil {
 			return fmt.Errorf("failed to parse action data: %w", err)
 		}
 
+		// Duplicate or replayed IDs must not be executed twice
+		if !c.pending.begin(action, c.actionResultTimeout(), func() { c.expireAction(action) }) {
+			c.logger.Warn("Ignoring duplicate action", "action", action.Name, "action_id", action.ID)
+			rec := newActionRecord(action, nil)
+			rec.Outcome = OutcomeDuplicate
+			c.recordHistory(rec)
+			return nil
+		}
+
 		// Handle action in goroutine to avoid blocking the read loop
 		go c.handleAction(action)
 
-	case "actions/reregister_all":
-		c.logger.Printf("Received reregister_all request")
+	case CommandReregisterAll:
+		c.logger.Info("Received reregister_all request")
 		// Resend all registered actions
-		go c.resendRegisteredActions()
+		go func() {
+			c.resendRegisteredActions()
+			c.reregisterModules()
+		}()
+
+	case CommandGracefulShutdown:
+		var data GracefulShutdownData
+		if err := json.Unmarshal(msg.Data, &data); err != nil {
+			return fmt.Errorf("failed to parse shutdown data: %w", err)
+		}
+		c.logger.Info("Received graceful shutdown request", "wants_shutdown", data.WantsShutdown)
+		if data.WantsShutdown {
+			go c.shutdownModules()
+		}
+
+	case CommandImmediateShutdown:
+		c.logger.Info("Received immediate shutdown request")
+		go c.shutdownModules()
 
 	default:
-		c.logger.Printf("Unhandled command: %s", msg.Command)
+		c.logger.Warn("Unhandled command", "command", msg.Command)
 	}
 
 	return nil
 }
 
 func (c *Client) handleAction(action IncomingAction) {
+	c.metrics.ActionReceived(action.Name)
+
+	c.metrics.QueueDepth("actions", int(atomic.AddInt32(&c.actionsInFlight, 1)))
+	defer func() {
+		c.metrics.QueueDepth("actions", int(atomic.AddInt32(&c.actionsInFlight, -1)))
+	}()
+
+	// Actions answering the latest force become child spans of its trace
+	force := c.observeForceAnswer(action.Name)
+	parent := context.Background()
+	if force != nil {
+		parent = force.ctx
+	}
+	ctx, span := c.tracer.Start(parent, SpanAction,
+		Attr("action.name", action.Name),
+		Attr("action.id", action.ID),
+		Attr("action.forced", force != nil),
+	)
+	defer span.End()
+
+	rec := newActionRecord(action, force)
+	defer func() { c.recordHistory(rec) }()
+	if force != nil {
+		defer func() { c.observeForceFailure(force, rec) }()
+	}
+
+	logger := c.logger.With("action", action.Name, "action_id", action.ID)
+
+	sceneEpoch := c.scenes.currentEpoch()
+
 	c.actionsMu.RLock()
 	handler, exists := c.actions[action.Name]
 	c.actionsMu.RUnlock()
 
 	if !exists {
-		c.logger.Printf("Unknown action: %s", action.Name)
-		c.SendActionResult(action.ID, false, fmt.Sprintf("Unknown action: %s", action.Name))
+		logger.Warn("Unknown action")
+		span.SetStatus(false, "unknown action")
+		rec.Outcome = OutcomeUnknownAction
+		c.sendActionResult(span, logger, &rec, false, fmt.Sprintf("Unknown action: %s", action.Name))
+		return
+	}
+
+	if !isAvailable(handler) {
+		logger.Info("Action is not available")
+		span.SetStatus(false, "action unavailable")
+		rec.Outcome = OutcomeUnavailable
+		c.sendActionResult(span, logger, &rec, false, fmt.Sprintf("Action %s is not available right now", action.Name))
 		return
 	}
 
-	c.logger.Printf("Handling action: %s (ID: %s)", action.Name, action.ID)
+	logger.Info("Handling action")
 
 	// Parse the JSON-stringified data from Neuro
 	var actionData json.RawMessage
 	if action.Data != "" {
 		// Data comes as a JSON string, need to parse it
 		if err := json.Unmarshal([]byte(action.Data), &actionData); err != nil {
-			c.logger.Printf("Failed to parse action data JSON: %v", err)
-			c.SendActionResult(action.ID, false, "Invalid JSON in action data")
+			logger.Warn("Failed to parse action data JSON", "error", err)
+			span.SetStatus(false, "invalid JSON in action data")
+			rec.Outcome = OutcomeInvalidData
+			c.sendActionResult(span, logger, &rec, false, "Invalid JSON in action data")
 			return
 		}
 	}
 
 	// Validate (data may be malformed or not match schema)
+	_, validateSpan := c.tracer.Start(ctx, SpanValidate)
+	start := time.Now()
 	state, result := handler.Validate(actionData)
+	rec.ValidationTime = time.Since(start)
+	c.metrics.ActionValidated(action.Name, result.Successful, rec.ValidationTime)
+	validateSpan.SetStatus(result.Successful, result.Message)
+	validateSpan.End()
+
+	logger.Info("Action validated", "success", result.Successful, "message", result.Message)
+	validated := result.Successful
+
+	// Hold the scene lock until the result is sent, so an action from a scene
+	// that was switched away from while validating is never accepted
+	c.scenes.mu.RLock()
+	rec.Outcome = OutcomeValidationFailed
+	if result.Successful && !c.scenes.accepts(action.Name, sceneEpoch) {
+		logger.Info("Action became unavailable after a scene switch")
+		result = NewFailureResult(fmt.Sprintf("Action %s is no longer available", action.Name))
+		rec.Outcome = OutcomeUnavailable
+	}
 
-	c.logger.Printf("Action validation result: success=%v, message=%s", result.Successful, result.Message)
-
-	// Send result immediately if validation failed
+	// Send the result before executing so Neuro is not kept waiting
 	if !result.Successful {
-		if err := c.SendActionResult(action.ID, result.Successful, result.Message); err != nil {
-			c.logger.Printf("Failed to send action result: %v", err)
+		c.sendActionResult(span, logger, &rec, false, result.Message)
+		c.scenes.mu.RUnlock()
+		if validated {
+			releaseReservation(handler, state)
 		}
+		span.SetStatus(false, result.Message)
+		return
+	}
+	sent := c.sendActionResult(span, logger, &rec, true, result.Message)
+	c.scenes.mu.RUnlock()
+
+	if !sent {
+		releaseReservation(handler, state)
+		span.SetStatus(false, "result not sent")
+		return
+	}
+
+	if force != nil {
+		c.completeForce(force, action, &ActionInvocation{
+			ID:      action.ID,
+			Name:    action.Name,
+			Params:  actionData,
+			State:   state,
+			Message: result.Message,
+		})
 	}
 
 	// Execute if successful
-	if result.Successful {
-		c.logger.Printf("Executing action: %s", action.Name)
-		handler.Execute(state)
+	logger.Debug("Executing action")
+	_, executeSpan := c.tracer.Start(ctx, SpanExecute)
+	start = time.Now()
+	handler.Execute(state)
+	rec.ExecutionTime = time.Since(start)
+	c.metrics.ActionExecuted(action.Name, rec.ExecutionTime)
+	executeSpan.End()
+	span.SetStatus(true, result.Message)
+	rec.Outcome = OutcomeExecuted
+}
+
+// reservingHandler is a handler that reserves something in Validate, which must be
+// released when the validated action is not executed after all
+type reservingHandler interface {
+	release(state interface{})
+}
+
+// releaseReservation releases what a handler reserved for an action that will not run
+func releaseReservation(handler ActionHandler, state interface{}) {
+	if h, ok := handler.(reservingHandler); ok {
+		h.release(state)
+	}
+}
+
+// newActionRecord starts a history record for an incoming action
+func newActionRecord(action IncomingAction, force *activeForce) ActionRecord {
+	rec := ActionRecord{
+		ID:       action.ID,
+		Name:     action.Name,
+		Received: time.Now(),
+	}
+	if action.Data != "" && json.Valid([]byte(action.Data)) {
+		rec.Params = json.RawMessage(action.Data)
+	}
+	if force != nil {
+		rec.Force = &ForceRecord{
+			Query:       force.query,
+			ActionNames: force.actionNames,
+			Priority:    force.priority,
+			ForcedAt:    force.at,
+		}
+	}
+	return rec
+}
+
+// sendActionResult sends an action result and records it on the action span and history record.
+// It returns false if the action was already answered (e.g. it expired) or the send failed.
+func (c *Client) sendActionResult(span Span, logger *slog.Logger, rec *ActionRecord, success bool, message string) bool {
+	span.AddEvent(CommandActionResult, Attr("success", success), Attr("message", message))
+	err := c.SendActionResult(rec.ID, success, message)
+	if err == nil {
+		rec.ResultMessage = message
+		return true
+	}
+
+	logger.Error("Failed to send action result", "error", err)
+	span.AddEvent("action/result failed", Attr("error", err.Error()))
+	if errors.Is(err, ErrResultAlreadySent) {
+		rec.Outcome = OutcomeExpired
+	} else {
+		rec.Outcome = OutcomeResultFailed
+	}
+	return false
+}
+
+// expireAction fails an action that did not receive a result in time
+func (c *Client) expireAction(action IncomingAction) {
+	err := c.SendActionResult(action.ID, false, "Action timed out")
+	if errors.Is(err, ErrResultAlreadySent) {
+		return
+	}
+
+	c.logger.Warn("Action timed out", "action", action.Name, "action_id", action.ID)
+	if err != nil {
+		c.logger.Error("Failed to send action result", "action", action.Name, "action_id", action.ID, "error", err)
 	}
 }
 
@@ -321,38 +624,71 @@ func (c *Client) send(msg Message) error {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 
-	c.logger.Printf("Sending: %s - %s", msg.Command, string(msgBytes))
-
-	if err := c.conn.WriteMessage(websocket.TextMessage, msgBytes); err != nil {
+	// Holding the write lock checks and records outbound messages in send order
+	c.writeMu.Lock()
+	commit, err := c.checkOutbound(msg)
+	if err != nil {
+		c.writeMu.Unlock()
+		return err
+	}
+	c.logPayload("Sending message", msg.Command, msgBytes)
+	err = c.conn.WriteMessage(websocket.TextMessage, msgBytes)
+	// Only messages that reached the connection are recorded
+	if err == nil {
+		commit()
+		c.record(DirectionOutbound, msg)
+	}
+	c.writeMu.Unlock()
+	if err != nil {
 		return fmt.Errorf("failed to send message: %w", err)
 	}
 
+	c.metrics.MessageSent(msg.Command)
+
 	return nil
 }
 
+// record passes a message to the configured recorder, if any
+func (c *Client) record(direction Direction, msg Message) {
+	if c.config.Recorder == nil {
+		return
+	}
+	if err := c.config.Recorder.Record(direction, msg); err != nil {
+		c.logger.Warn("Failed to record message", "command", msg.Command, "error", err)
+	}
+}
+
 // Startup sends the initial startup message
 func (c *Client) Startup() error {
-	c.logger.Printf("Sending startup message...")
-	return c.send(Message{Command: "startup", Game: c.config.Game})
+	c.logger.Info("Sending startup message")
+	if err := c.send(Message{Command: CommandStartup, Game: c.config.Game}); err != nil {
+		return err
+	}
+
+	// Startup clears all actions on Neuro's side
+	c.actionsMu.Lock()
+	c.sent = make(map[string][]byte)
+	c.actionsMu.Unlock()
+
+	return nil
 }
 
 // SendContext sends a context message to Neuro
 func (c *Client) SendContext(message string, silent bool) error {
-	data := map[string]interface{}{
-		"message": message,
-		"silent":  silent,
-	}
-	dataBytes, _ := json.Marshal(data)
+	dataBytes, _ := json.Marshal(ContextData{
+		Message: message,
+		Silent:  silent,
+	})
 
 	return c.send(Message{
-		Command: "context",
+		Command: CommandContext,
 		Data:    dataBytes,
 	})
 }
 
 // SendShutdownReady notifies Neuro that the integration is ready to shut down
 func (c *Client) SendShutdownReady() error {
-	return c.send(Message{Command: "shutdown/ready"})
+	return c.send(Message{Command: CommandShutdownReady})
 }
 
 // Action Management
@@ -362,7 +698,8 @@ func (c *Client) RegisterAction(handler ActionHandler) error {
 	return c.RegisterActions([]ActionHandler{handler})
 }
 
-// RegisterActions registers multiple action handlers
+// RegisterActions registers multiple action handlers.
+// If they cannot be sent, e.g. because the client is not connected, none are registered.
 func (c *Client) RegisterActions(handlers []ActionHandler) error {
 	if len(handlers) == 0 {
 		return nil
@@ -371,15 +708,78 @@ func (c *Client) RegisterActions(handlers []ActionHandler) error {
 	c.actionsMu.Lock()
 	defer c.actionsMu.Unlock()
 
+	return c.registerActionsLocked(handlers)
+}
+
+// DeclareActions adds handlers to the client without sending them to Neuro.
+// Declared actions are registered with Neuro when the client connects, and are
+// included in ExportActions, so a game can collect its actions without a connection.
+// Declaring on a connected client only takes effect on the next connection.
+func (c *Client) DeclareActions(handlers []ActionHandler) error {
+	c.actionsMu.Lock()
+	defer c.actionsMu.Unlock()
+
+	for _, h := range handlers {
+		if h.GetName() == "" {
+			return errors.New("action name cannot be empty")
+		}
+	}
+	for _, h := range handlers {
+		c.actions[h.GetName()] = h
+	}
+	return nil
+}
+
+// sendRegisteredActions sends every registered, available action that Neuro does
+// not have yet, e.g. after startup cleared them
+func (c *Client) sendRegisteredActions() error {
+	c.actionsMu.Lock()
+	defer c.actionsMu.Unlock()
+
+	var defs []ActionDefinition
+	for _, name := range sortedKeys(c.actions) {
+		h := c.actions[name]
+		if _, sent := c.sent[name]; sent || !isAvailable(h) {
+			continue
+		}
+		defs = append(defs, ActionDefinition{
+			Name:        name,
+			Description: h.GetDescription(),
+			Schema:      h.GetSchema(),
+		})
+	}
+	if len(defs) == 0 {
+		return nil
+	}
+	return c.sendRegister(defs)
+}
+
+// registerActionsLocked stores handlers and sends the available ones to Neuro.
+// If sending fails, the previous handlers are restored. Must be called with actionsMu held.
+func (c *Client) registerActionsLocked(handlers []ActionHandler) error {
 	actions := make([]ActionDefinition, 0, len(handlers))
+	previous := make(map[string]ActionHandler, len(handlers))
+	var hidden []string
 	for _, h := range handlers {
 		name := h.GetName()
 		if name == "" {
+			c.restoreHandlers(previous)
 			return errors.New("action name cannot be empty")
 		}
 
+		if _, seen := previous[name]; !seen {
+			previous[name] = c.actions[name]
+		}
 		c.actions[name] = h
 
+		// Unavailable actions are only sent once Tick finds them available
+		if !isAvailable(h) {
+			if _, ok := c.sent[name]; ok {
+				hidden = append(hidden, name)
+			}
+			continue
+		}
+
 		actions = append(actions, ActionDefinition{
 			Name:        name,
 			Description: h.GetDescription(),
@@ -387,17 +787,42 @@ func (c *Client) RegisterActions(handlers []ActionHandler) error {
 		})
 	}
 
-	data := map[string]interface{}{
-		"actions": actions,
+	var err error
+	if len(hidden) > 0 {
+		err = c.sendUnregister(hidden)
+	}
+	if err == nil && len(actions) > 0 {
+		err = c.sendRegister(actions)
+	}
+	// A failed registration must not leave handlers Neuro never received
+	if err != nil {
+		c.restoreHandlers(previous)
+	}
+	return err
+}
+
+// sendRegister sends action definitions and remembers what was sent.
+// Must be called with actionsMu held.
+func (c *Client) sendRegister(actions []ActionDefinition) error {
+	// Schemas are arbitrary maps, so they may not marshal (e.g. an infinite bound)
+	dataBytes, err := json.Marshal(RegisterActionsData{Actions: actions})
+	if err != nil {
+		return fmt.Errorf("failed to marshal actions: %w", err)
 	}
-	dataBytes, _ := json.Marshal(data)
 
-	c.logger.Printf("Registering %d action(s)", len(actions))
+	c.logger.Info("Registering actions", "count", len(actions))
 
-	return c.send(Message{
-		Command: "actions/register",
+	if err := c.send(Message{
+		Command: CommandRegisterActions,
 		Data:    dataBytes,
-	})
+	}); err != nil {
+		return err
+	}
+
+	for _, a := range actions {
+		c.sent[a.Name] = definitionSnapshot(a)
+	}
+	return nil
 }
 
 // UnregisterAction unregisters a single action by name
@@ -405,28 +830,63 @@ func (c *Client) UnregisterAction(name string) error {
 	return c.UnregisterActions([]string{name})
 }
 
-// UnregisterActions unregisters multiple actions by name
+// UnregisterActions unregisters multiple actions by name.
+// If they cannot be unregistered, e.g. because the client is not connected, the handlers stay registered.
 func (c *Client) UnregisterActions(names []string) error {
 	if len(names) == 0 {
 		return nil
 	}
 
 	c.actionsMu.Lock()
-	defer c.actionsMu.Unlock()
-
+	previous := make(map[string]ActionHandler, len(names))
 	for _, name := range names {
+		if _, seen := previous[name]; !seen {
+			previous[name] = c.actions[name]
+		}
 		delete(c.actions, name)
 	}
 
-	data := map[string]interface{}{
-		"action_names": names,
+	// Handlers stay registered if Neuro could not be told to forget them
+	err := c.sendUnregister(names)
+	if err != nil {
+		c.restoreHandlers(previous)
 	}
-	dataBytes, _ := json.Marshal(data)
+	c.actionsMu.Unlock()
 
-	return c.send(Message{
-		Command: "actions/unregister",
+	if err == nil {
+		c.supersedeStaleForce()
+	}
+	return err
+}
+
+// sendUnregister unregisters actions on Neuro's side without touching the handlers.
+// Must be called with actionsMu held.
+func (c *Client) sendUnregister(names []string) error {
+	dataBytes, _ := json.Marshal(UnregisterActionsData{ActionNames: names})
+
+	if err := c.send(Message{
+		Command: CommandUnregisterActions,
 		Data:    dataBytes,
-	})
+	}); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		delete(c.sent, name)
+	}
+	return nil
+}
+
+// restoreHandlers puts back the handlers that were registered before a change.
+// Must be called with actionsMu held.
+func (c *Client) restoreHandlers(previous map[string]ActionHandler) {
+	for name, h := range previous {
+		if h == nil {
+			delete(c.actions, name)
+		} else {
+			c.actions[name] = h
+		}
+	}
 }
 
 func (c *Client) resendRegisteredActions() {
@@ -438,9 +898,9 @@ func (c *Client) resendRegisteredActions() {
 	c.actionsMu.RUnlock()
 
 	if len(handlers) > 0 {
-		c.logger.Printf("Re-registering %d action(s)", len(handlers))
+		c.logger.Info("Re-registering actions", "count", len(handlers))
 		if err := c.RegisterActions(handlers); err != nil {
-			c.logger.Printf("Failed to resend registered actions: %v", err)
+			c.logger.Error("Failed to resend registered actions", "error", err)
 		}
 	}
 }
@@ -451,6 +911,16 @@ func (c *Client) ForceActions(query string, actionNames []string, opts ...ForceO
 		return errors.New("must specify at least one action name")
 	}
 
+	if c.config.QueueForces {
+		_, err := c.QueueForce(query, actionNames, opts...)
+		return err
+	}
+
+	return c.sendForce(query, actionNames, newForceConfig(opts))
+}
+
+// newForceConfig applies force options over the defaults
+func newForceConfig(opts []ForceOption) *forceConfig {
 	config := &forceConfig{
 		priority:         PriorityLow,
 		ephemeralContext: false,
@@ -459,24 +929,151 @@ func (c *Client) ForceActions(query string, actionNames []string, opts ...ForceO
 	for _, opt := range opts {
 		opt(config)
 	}
+	return config
+}
 
-	data := map[string]interface{}{
-		"query":             query,
-		"action_names":      actionNames,
-		"ephemeral_context": config.ephemeralContext,
-		"priority":          config.priority,
+// sendForce sends an action force and starts tracking it
+func (c *Client) sendForce(query string, actionNames []string, config *forceConfig) error {
+	if c.config.RefreshBeforeForce {
+		if err := c.refreshActions(actionNames, true); err != nil {
+			return fmt.Errorf("failed to refresh actions before force: %w", err)
+		}
 	}
 
-	if config.state != "" {
-		data["state"] = config.state
-	}
+	dataBytes, _ := json.Marshal(ForceActionsData{
+		State:            c.forceState(config.state),
+		Query:            query,
+		EphemeralContext: config.ephemeralContext,
+		Priority:         config.priority,
+		ActionNames:      actionNames,
+	})
 
-	dataBytes, _ := json.Marshal(data)
+	// Track the force before sending so an immediate answer is not missed
+	force := c.recordForce(query, actionNames, config)
+	if force == nil {
+		// The escalated force was answered or superseded meanwhile, so there is nothing to re-issue
+		return nil
+	}
 
-	return c.send(Message{
-		Command: "actions/force",
+	if err := c.send(Message{
+		Command: CommandForceActions,
 		Data:    dataBytes,
-	})
+	}); err != nil {
+		c.forceMu.Lock()
+		if c.lastForce == force {
+			c.lastForce = nil
+		}
+		if force.waiter != nil {
+			force.waiter.finish(nil, err)
+			force.waiter = nil
+		}
+		c.forceMu.Unlock()
+		force.span.SetStatus(false, err.Error())
+		force.span.End()
+		return err
+	}
+
+	if config.escalation != nil {
+		c.scheduleEscalation(force, actionNames, config)
+	}
+	return nil
+}
+
+// recordForce starts tracking a force, superseding any previous unanswered one.
+// An escalation takes over the waiter, start time and queue deadline of the force
+// it re-issues, or returns nil if that force is no longer active.
+func (c *Client) recordForce(query string, actionNames []string, config *forceConfig) *activeForce {
+	ctx, span := c.tracer.Start(context.Background(), SpanForce,
+		Attr("force.query", query),
+		Attr("force.action_names", actionNames),
+		Attr("force.priority", string(config.priority)),
+		Attr("force.ephemeral_context", config.ephemeralContext),
+	)
+
+	force := &activeForce{
+		query:       query,
+		actionNames: append([]string(nil), actionNames...),
+		priority:    config.priority,
+		names:       make(map[string]bool, len(actionNames)),
+		at:          time.Now(),
+		ctx:         ctx,
+		span:        span,
+		waiter:      config.waiter,
+	}
+	for _, name := range actionNames {
+		force.names[name] = true
+	}
+
+	c.forceMu.Lock()
+	previous := c.lastForce
+	if config.escalates != nil {
+		if previous != config.escalates {
+			c.forceMu.Unlock()
+			span.SetStatus(false, "force no longer active")
+			span.End()
+			return nil
+		}
+		force.at = previous.at
+		force.answered = previous.answered
+		force.waiter, previous.waiter = previous.waiter, nil
+		previous.escalatedTo = force
+		if previous.queueTimer != nil {
+			previous.queueTimer.Stop()
+			c.armQueueTimeout(force)
+		}
+	}
+	c.lastForce = force
+	if previous != nil && previous.waiter != nil {
+		previous.waiter.finish(nil, ErrForceSuperseded)
+		previous.waiter = nil
+	}
+	c.forceMu.Unlock()
+
+	if previous != nil {
+		previous.span.SetStatus(false, "superseded by a newer force")
+		previous.span.End()
+	}
+
+	return force
+}
+
+// observeForceAnswer returns the latest force if the action answers it,
+// reporting force-to-action latency for the first answer
+func (c *Client) observeForceAnswer(name string) *activeForce {
+	c.forceMu.Lock()
+	defer c.forceMu.Unlock()
+
+	force := c.lastForce
+	if force == nil || !force.names[name] {
+		return nil
+	}
+	if !force.answered {
+		force.answered = true
+		c.metrics.ForceAnswered(time.Since(force.at))
+	}
+	return force
+}
+
+// completeForce ends the force trace once an action answering it passes validation
+func (c *Client) completeForce(force *activeForce, action IncomingAction, invocation *ActionInvocation) {
+	c.forceMu.Lock()
+	for force.escalatedTo != nil {
+		force = force.escalatedTo
+	}
+	if c.lastForce == force {
+		c.lastForce = nil
+	}
+	if force.waiter != nil {
+		force.waiter.finish(invocation, nil)
+		force.waiter = nil
+	}
+	c.forceMu.Unlock()
+
+	force.span.SetAttributes(Attr("force.answered_by", action.Name), Attr("force.answer_id", action.ID))
+	force.span.SetStatus(true, "answered")
+	force.span.End()
+
+	c.dispatchForces()
 }
 
 // ForceOption configures action forcing
@@ -486,6 +1083,11 @@ type forceConfig struct {
 	state            string
 	ephemeralContext bool
 	priority         Priority
+	maxAttempts      int
+	waiter           *forceWaiter
+	escalation       *escalation
+	// escalates is the force this one re-issues, which it only replaces while still active
+	escalates *activeForce
 }
 
 // WithState adds state information to the action force
@@ -509,19 +1111,30 @@ func WithPriority(priority Priority) ForceOption {
 	}
 }
 
-// SendActionResult sends the result of an action execution
+// SendActionResult sends the result of an action execution.
+// Exactly one result can be sent per action ID; later calls return ErrResultAlreadySent
+// and IDs Neuro never issued return ErrUnknownActionID.
 func (c *Client) SendActionResult(id string, success bool, message string) error {
-	data := map[string]interface{}{
-		"id":      id,
-		"success": success,
-		"message": message,
+	if err := c.pending.claim(id); err != nil {
+		return fmt.Errorf("cannot send result for action %q: %w", id, err)
 	}
-	dataBytes, _ := json.Marshal(data)
 
-	return c.send(Message{
-		Command: "action/result",
-		Data:    dataBytes,
+	dataBytes, _ := json.Marshal(ActionResultData{
+		ID:      id,
+		Success: success,
+		Message: message,
 	})
+
+	// The ID is only consumed once the result is actually sent, so it can be retried
+	if err := c.send(Message{
+		Command: CommandActionResult,
+		Data:    dataBytes,
+	}); err != nil {
+		c.pending.release(id)
+		return err
+	}
+	c.pending.complete(id)
+	return nil
 }
 
 // Channels
@@ -539,6 +1152,14 @@ func (c *Client) Errors() <-chan error {
 
 // Close closes the websocket connection
 func (c *Client) Close() error {
+	// Modules may still want to send messages while shutting down
+	c.connMu.RLock()
+	closed := c.closed
+	c.connMu.RUnlock()
+	if !closed {
+		c.shutdownModules()
+	}
+
 	c.connMu.Lock()
 	defer c.connMu.Unlock()
 
@@ -546,7 +1167,7 @@ func (c *Client) Close() error {
 		return nil
 	}
 
-	c.logger.Printf("Closing client...")
+	c.logger.Info("Closing client")
 	c.closed = true
 	close(c.closeChan)
 
@@ -604,7 +1225,7 @@ func (w *ActionWindow) AddAction(handler ActionHandler) *ActionWindow {
 	defer w.mu.Unlock()
 
 	if w.registered {
-		w.client.logger.Printf("Cannot add action to registered window")
+		w.client.logger.Warn("Cannot add action to registered window", "action", handler.GetName())
 		return w
 	}
 
@@ -618,7 +1239,7 @@ func (w *ActionWindow) SetForce(query string, opts ...ForceOption) *ActionWindow
 	defer w.mu.Unlock()
 
 	if w.registered {
-		w.client.logger.Printf("Cannot modify registered window")
+		w.client.logger.Warn("Cannot modify registered window")
 		return w
 	}
 
@@ -655,9 +1276,9 @@ func (w *ActionWindow) Register() error {
 	// Force actions after a brief delay to ensure registration completes
 	go func() {
 		time.Sleep(100 * time.Millisecond)
-		w.client.logger.Printf("Forcing actions in window: %v", names)
+		w.client.logger.Info("Forcing actions in window", "actions", names)
 		if err := w.client.ForceActions(w.query, names, w.forceOpts...); err != nil {
-			w.client.logger.Printf("Failed to force actions: %v", err)
+			w.client.logger.Error("Failed to force actions", "error", err)
 		}
 	}()
 
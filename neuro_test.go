@@ -0,0 +1,332 @@
+package neuro
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// testServer plays Neuro's side of the websocket connection
+type testServer struct {
+	t     *testing.T
+	srv   *httptest.Server
+	conns chan *websocket.Conn
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	s := &testServer{t: t, conns: make(chan *websocket.Conn, 4)}
+	upgrader := websocket.Upgrader{}
+	s.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		s.conns <- conn
+	}))
+	t.Cleanup(s.srv.Close)
+	return s
+}
+
+func (s *testServer) url() string {
+	return "ws" + strings.TrimPrefix(s.srv.URL, "http")
+}
+
+// accept waits for the client to connect
+func (s *testServer) accept() *testConn {
+	s.t.Helper()
+
+	select {
+	case conn := <-s.conns:
+		s.t.Cleanup(func() { conn.Close() })
+		return &testConn{t: s.t, conn: conn}
+	case <-time.After(2 * time.Second):
+		s.t.Fatal("client did not connect")
+		return nil
+	}
+}
+
+// testConn is the server end of one client connection
+type testConn struct {
+	t    *testing.T
+	conn *websocket.Conn
+}
+
+// read returns the next message from the client
+func (c *testConn) read() Message {
+	c.t.Helper()
+
+	c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg Message
+	if err := c.conn.ReadJSON(&msg); err != nil {
+		c.t.Fatalf("failed to read message: %v", err)
+	}
+	return msg
+}
+
+// expect reads the next message and fails the test if it is not command
+func (c *testConn) expect(command string) Message {
+	c.t.Helper()
+
+	msg := c.read()
+	if msg.Command != command {
+		c.t.Fatalf("got %s %s, want %s", msg.Command, msg.Data, command)
+	}
+	return msg
+}
+
+// expectResult reads the next message as an action result
+func (c *testConn) expectResult() ActionResultData {
+	c.t.Helper()
+
+	msg := c.expect(CommandActionResult)
+	var result ActionResultData
+	if err := json.Unmarshal(msg.Data, &result); err != nil {
+		c.t.Fatalf("invalid action result: %v", err)
+	}
+	return result
+}
+
+// sendAction sends an action as Neuro would, with data JSON-stringified
+func (c *testConn) sendAction(id, name, data string) {
+	c.t.Helper()
+
+	c.send(CommandAction, IncomingAction{ID: id, Name: name, Data: data})
+}
+
+func (c *testConn) send(command string, data interface{}) {
+	c.t.Helper()
+
+	msg := map[string]interface{}{"command": command}
+	if data != nil {
+		msg["data"] = data
+	}
+	if err := c.conn.WriteJSON(msg); err != nil {
+		c.t.Fatalf("failed to send %s: %v", command, err)
+	}
+}
+
+// discardLogs keeps client logs out of the test output
+var discardLogs = slog.NewTextHandler(io.Discard, nil)
+
+// newTestClient creates a client for the server that discards its logs.
+// configure can change the config before the client is created.
+func newTestClient(t *testing.T, s *testServer, configure func(*ClientConfig)) *Client {
+	t.Helper()
+
+	config := ClientConfig{
+		Game:         "Test Game",
+		WebsocketURL: s.url(),
+		LogHandler:   discardLogs,
+	}
+	if configure != nil {
+		configure(&config)
+	}
+	c, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// connect connects the client and reads its startup message
+func connect(t *testing.T, c *Client, s *testServer) *testConn {
+	t.Helper()
+
+	if err := c.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	conn := s.accept()
+	conn.expect(CommandStartup)
+	return conn
+}
+
+// testAction is an action whose Validate can be held back by the test
+type testAction struct {
+	name    string
+	release chan struct{} // Validate waits for it to be closed, if set
+}
+
+func (a *testAction) GetName() string          { return a.name }
+func (a *testAction) GetDescription() string   { return "Test action " + a.name }
+func (a *testAction) GetSchema() *ActionSchema { return nil }
+func (a *testAction) Execute(interface{})      {}
+
+func (a *testAction) Validate(json.RawMessage) (interface{}, ExecutionResult) {
+	if a.release != nil {
+		<-a.release
+	}
+	return nil, NewSuccessResult("done")
+}
+
+func TestDuplicateActionIDGetsOneResult(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	if err := c.RegisterAction(&testAction{name: "jump"}); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+
+	conn.sendAction("1", "jump", "")
+	if result := conn.expectResult(); result.ID != "1" || !result.Success {
+		t.Fatalf("got result %+v, want success for 1", result)
+	}
+
+	// A replayed ID is ignored, so the next result is for the next action
+	conn.sendAction("1", "jump", "")
+	conn.sendAction("2", "jump", "")
+	if result := conn.expectResult(); result.ID != "2" {
+		t.Fatalf("got result for %q, want 2", result.ID)
+	}
+}
+
+func TestSendActionResultErrors(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	if err := c.RegisterAction(&testAction{name: "jump"}); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+	conn.sendAction("1", "jump", "")
+	conn.expectResult()
+
+	tests := []struct {
+		name string
+		id   string
+		want error
+	}{
+		{"unknown ID", "never-sent", ErrUnknownActionID},
+		{"already answered", "1", ErrResultAlreadySent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := c.SendActionResult(tt.id, true, ""); !errors.Is(err, tt.want) {
+				t.Errorf("SendActionResult(%q) = %v, want %v", tt.id, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestActionResultTimeout(t *testing.T) {
+	s := newTestServer(t)
+	history := NewMemoryHistory(10)
+	c := newTestClient(t, s, func(config *ClientConfig) {
+		config.ActionResultTimeout = 50 * time.Millisecond
+		config.History = history
+	})
+	conn := connect(t, c, s)
+
+	slow := &testAction{name: "slow", release: make(chan struct{})}
+	if err := c.RegisterAction(slow); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+
+	conn.sendAction("1", "slow", "")
+	result := conn.expectResult()
+	if result.ID != "1" || result.Success || result.Message != "Action timed out" {
+		t.Fatalf("got result %+v, want timeout failure", result)
+	}
+
+	// The late result from Validate must not be sent as a second result
+	close(slow.release)
+	waitFor(t, "action to be recorded", func() bool {
+		records, _ := history.Query(HistoryQuery{Outcomes: []ActionOutcome{OutcomeExpired}})
+		return len(records) == 1
+	})
+	if err := c.SendContext("marker", true); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandContext)
+}
+
+func TestPendingActionsClearedOnReconnect(t *testing.T) {
+	s := newTestServer(t)
+	history := NewMemoryHistory(10)
+	c := newTestClient(t, s, func(config *ClientConfig) {
+		config.History = history
+	})
+	conn := connect(t, c, s)
+
+	slow := &testAction{name: "slow", release: make(chan struct{})}
+	if err := c.RegisterAction(slow); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+
+	conn.sendAction("1", "slow", "")
+	waitFor(t, "action to be pending", func() bool { return len(c.PendingActions()) == 1 })
+
+	conn.conn.Close()
+	<-c.Errors()
+	conn = connect(t, c, s)
+	if pending := c.PendingActions(); len(pending) != 0 {
+		t.Fatalf("PendingActions() = %v after reconnecting, want none", pending)
+	}
+
+	// The action from the old connection finishes, but its result is not sent on the new one
+	close(slow.release)
+	waitFor(t, "action to be recorded", func() bool {
+		records, _ := history.Query(HistoryQuery{Name: "slow"})
+		return len(records) == 1
+	})
+	if err := c.SendContext("marker", true); err != nil {
+		t.Fatal(err)
+	}
+	// Reconnecting sends the registered action again before anything else
+	conn.expect(CommandRegisterActions)
+	conn.expect(CommandContext)
+}
+
+func TestConcurrentSends(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	const senders = 20
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.SendContext("tick", true); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < senders; i++ {
+		conn.expect(CommandContext)
+	}
+}
+
+// waitFor polls cond until it is true or the test times out
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,531 @@
+package neuro
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Object Actions
+
+// ActionSpec describes an action method registered by RegisterObject
+type ActionSpec struct {
+	// Name overrides the action name derived from the method name (optional)
+	Name string
+	// Description is sent to Neuro (default derived from the method name)
+	Description string
+}
+
+// ActionSpecProvider is implemented by objects that list their action methods
+// explicitly, keyed by Go method name. Listed methods do not need the Action prefix.
+type ActionSpecProvider interface {
+	ActionSpecs() map[string]ActionSpec
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// RegisterObject registers the action methods of obj. Errors returned by the
+// methods are logged by the client. See ObjectActions.
+func (c *Client) RegisterObject(obj interface{}) error {
+	handlers, err := ObjectActions(obj)
+	if err != nil {
+		return err
+	}
+	for _, h := range handlers {
+		h.(*methodHandler).logger = c.logger
+	}
+	return c.RegisterActions(handlers)
+}
+
+// ObjectActions turns the action methods of obj into handlers.
+//
+// Exported methods named Action<Name> are actions named <name> in snake_case,
+// e.g. ActionGiveItem becomes "give_item". If obj implements ActionSpecProvider,
+// the methods it lists are actions too, with the given names and descriptions.
+//
+// An action method takes no arguments or a single params struct (or pointer to
+// one), and returns nothing or an error:
+//
+//	func (g *Game) ActionGiveItem(p GiveItemParams) error
+//
+// The schema is derived from the params struct. Properties are named after the
+// json tag, and a neuro tag adds constraints that are checked while the action
+// is validated:
+//
+//	Item     string `json:"item" neuro:"required,enum=sword|shield"`
+//	Quantity int    `json:"quantity" neuro:"min=1,max=99"`
+//
+// Supported tag options are required, enum (separated by |), min, max,
+// minLength, maxLength and pattern. Fields of embedded structs are flattened
+// into the schema as encoding/json does, unless a json tag names them. For checks that depend on game state, add a
+// method named Validate<Name> with the same arguments that returns an error;
+// its error fails the action with the error text as the message:
+//
+//	func (g *Game) ValidateGiveItem(p GiveItemParams) error
+//
+// The action method runs like Execute, after the successful result was sent,
+// so an error it returns can no longer fail the action and is only logged.
+func ObjectActions(obj interface{}) ([]ActionHandler, error) {
+	v := reflect.ValueOf(obj)
+	if !v.IsValid() {
+		return nil, errors.New("object cannot be nil")
+	}
+	t := v.Type()
+
+	var specs map[string]ActionSpec
+	if p, ok := obj.(ActionSpecProvider); ok {
+		specs = p.ActionSpecs()
+	}
+	for method := range specs {
+		if _, ok := t.MethodByName(method); !ok {
+			return nil, fmt.Errorf("ActionSpecs lists unknown method %s", method)
+		}
+	}
+
+	var handlers []ActionHandler
+	for i := 0; i < t.NumMethod(); i++ {
+		m := t.Method(i)
+		spec, listed := specs[m.Name]
+		if !listed && !isActionMethodName(m.Name) {
+			continue
+		}
+
+		h, err := newMethodHandler(v, m.Name, spec)
+		if err != nil {
+			return nil, err
+		}
+		handlers = append(handlers, h)
+	}
+
+	if len(handlers) == 0 {
+		return nil, fmt.Errorf("%s has no action methods", t)
+	}
+	sort.Slice(handlers, func(i, j int) bool { return handlers[i].GetName() < handlers[j].GetName() })
+	return handlers, nil
+}
+
+// isActionMethodName reports whether a method follows the Action<Name> convention
+func isActionMethodName(name string) bool {
+	rest := strings.TrimPrefix(name, "Action")
+	if rest == name || rest == "" || name == "ActionSpecs" {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(rest)
+	return unicode.IsUpper(r)
+}
+
+// methodHandler dispatches an action to a method
+type methodHandler struct {
+	name        string
+	description string
+	method      reflect.Value
+	validator   reflect.Value // Validate<Name> method (invalid if there is none)
+	params      reflect.Type  // nil if the method takes no arguments
+	pointer     bool          // the method takes a pointer to params
+	fields      []paramField
+	schema      *ActionSchema
+	logger      *slog.Logger // receives method errors (nil discards them)
+}
+
+func newMethodHandler(obj reflect.Value, methodName string, spec ActionSpec) (*methodHandler, error) {
+	method := obj.MethodByName(methodName)
+	mt := method.Type()
+	if mt.NumOut() > 1 || (mt.NumOut() == 1 && mt.Out(0) != errorType) {
+		return nil, fmt.Errorf("action method %s must return nothing or an error", methodName)
+	}
+	if mt.NumIn() > 1 {
+		return nil, fmt.Errorf("action method %s must take at most one params struct", methodName)
+	}
+
+	base := strings.TrimPrefix(methodName, "Action")
+	if base == "" {
+		base = methodName
+	}
+
+	h := &methodHandler{
+		name:        spec.Name,
+		description: spec.Description,
+		method:      method,
+	}
+
+	if validator := obj.MethodByName("Validate" + base); validator.IsValid() {
+		vt := validator.Type()
+		if vt.NumIn() != mt.NumIn() || (mt.NumIn() == 1 && vt.In(0) != mt.In(0)) ||
+			vt.NumOut() != 1 || vt.Out(0) != errorType {
+			return nil, fmt.Errorf("validator Validate%s must take the same arguments as %s and return an error", base, methodName)
+		}
+		h.validator = validator
+	}
+	if h.name == "" {
+		h.name = snakeCase(base)
+	}
+	if h.description == "" {
+		h.description = strings.ReplaceAll(h.name, "_", " ")
+		r, size := utf8.DecodeRuneInString(h.description)
+		h.description = string(unicode.ToUpper(r)) + h.description[size:]
+	}
+
+	if mt.NumIn() == 1 {
+		pt := mt.In(0)
+		if pt.Kind() == reflect.Ptr {
+			h.pointer = true
+			pt = pt.Elem()
+		}
+		if pt.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("action method %s must take a params struct, not %s", methodName, mt.In(0))
+		}
+		h.params = pt
+
+		fields, err := paramFields(pt)
+		if err != nil {
+			return nil, fmt.Errorf("action method %s: %w", methodName, err)
+		}
+		h.fields = fields
+		h.schema = fieldsSchema(fields)
+	}
+
+	return h, nil
+}
+
+func (h *methodHandler) GetName() string {
+	return h.name
+}
+
+func (h *methodHandler) GetDescription() string {
+	return h.description
+}
+
+func (h *methodHandler) GetSchema() *ActionSchema {
+	return h.schema
+}
+
+// Validate decodes and checks the parameters and returns the method arguments as state
+func (h *methodHandler) Validate(data json.RawMessage) (interface{}, ExecutionResult) {
+	var args []reflect.Value
+
+	if h.params != nil {
+		var raw map[string]json.RawMessage
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &raw); err != nil {
+				return nil, NewFailureResult("Parameters must be a JSON object")
+			}
+		}
+		for _, f := range h.fields {
+			if msg := f.check(raw[f.name]); msg != "" {
+				return nil, NewFailureResult(msg)
+			}
+		}
+
+		params := reflect.New(h.params)
+		if err := ParseActionData(data, params.Interface()); err != nil {
+			return nil, NewFailureResult(fmt.Sprintf("Invalid parameters: %v", err))
+		}
+		if h.pointer {
+			args = []reflect.Value{params}
+		} else {
+			args = []reflect.Value{params.Elem()}
+		}
+	}
+
+	if h.validator.IsValid() {
+		if err := callError(h.validator, args); err != nil {
+			return nil, NewFailureResult(err.Error())
+		}
+	}
+
+	return args, NewSuccessResult("")
+}
+
+// Execute calls the method with the arguments decoded by Validate
+func (h *methodHandler) Execute(state interface{}) {
+	args, _ := state.([]reflect.Value)
+	if err := callError(h.method, args); err != nil && h.logger != nil {
+		h.logger.Warn("Action method failed", "action", h.name, "error", err)
+	}
+}
+
+// callError calls a method and returns its error result, if it has one
+func callError(method reflect.Value, args []reflect.Value) error {
+	out := method.Call(args)
+	if len(out) == 0 || out[0].IsNil() {
+		return nil
+	}
+	return out[0].Interface().(error)
+}
+
+// paramField is a params struct field exposed in the schema
+type paramField struct {
+	name      string
+	kind      string
+	items     string
+	required  bool
+	enum      []string
+	min, max  *float64
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+}
+
+// paramFields reads the schema properties of a params struct. Fields of embedded
+// structs are flattened like encoding/json does, unless a json tag names them.
+func paramFields(t reflect.Type) ([]paramField, error) {
+	return structFields(t, map[reflect.Type]bool{})
+}
+
+// structFields reads the fields of t and its embedded structs. embedding holds
+// the structs t is embedded in, so a struct embedding itself through a pointer stops.
+func structFields(t reflect.Type, embedding map[reflect.Type]bool) ([]paramField, error) {
+	embedding[t] = true
+	defer delete(embedding, t)
+
+	var fields, promoted []paramField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		name := sf.Name
+		tagName, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if tagName == "-" {
+			continue
+		}
+		if tagName != "" {
+			name = tagName
+		}
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		// encoding/json cannot set fields through an embedded pointer to an unexported type
+		if sf.Anonymous && tagName == "" && ft.Kind() == reflect.Struct && (sf.IsExported() || sf.Type.Kind() != reflect.Ptr) {
+			if embedding[ft] {
+				continue
+			}
+			embedded, err := structFields(ft, embedding)
+			if err != nil {
+				return nil, fmt.Errorf("embedded %s: %w", sf.Name, err)
+			}
+			promoted = append(promoted, embedded...)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+
+		f := paramField{name: name, kind: jsonKind(ft)}
+		if f.kind == "" {
+			return nil, fmt.Errorf("field %s has unsupported type %s", sf.Name, sf.Type)
+		}
+		if f.kind == "array" {
+			f.items = jsonKind(ft.Elem())
+		}
+
+		if err := f.parseTag(sf.Tag.Get("neuro")); err != nil {
+			return nil, fmt.Errorf("field %s: %w", sf.Name, err)
+		}
+		if f.kind != "string" && (f.enum != nil || f.minLength != nil || f.maxLength != nil || f.pattern != nil) {
+			return nil, fmt.Errorf("field %s: enum, minLength, maxLength and pattern need a string field", sf.Name)
+		}
+		if f.kind != "integer" && f.kind != "number" && (f.min != nil || f.max != nil) {
+			return nil, fmt.Errorf("field %s: min and max need a numeric field", sf.Name)
+		}
+		fields = append(fields, f)
+	}
+
+	// The struct's own fields hide promoted ones of the same name
+	defined := make(map[string]bool, len(fields)+len(promoted))
+	for _, f := range fields {
+		defined[f.name] = true
+	}
+	fromEmbedded := make(map[string]bool, len(promoted))
+	for _, f := range promoted {
+		if defined[f.name] {
+			continue
+		}
+		if fromEmbedded[f.name] {
+			return nil, fmt.Errorf("field %s is defined by more than one embedded struct", f.name)
+		}
+		fromEmbedded[f.name] = true
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// jsonKind maps a Go type to a JSON schema type
+func jsonKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	default:
+		return ""
+	}
+}
+
+func (f *paramField) parseTag(tag string) error {
+	if tag == "" {
+		return nil
+	}
+	for _, opt := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(opt, "=")
+		switch key {
+		case "required":
+			f.required = true
+		case "enum":
+			f.enum = strings.Split(value, "|")
+		case "min", "max":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid %s %q", key, value)
+			}
+			if key == "min" {
+				f.min = &n
+			} else {
+				f.max = &n
+			}
+		case "minLength", "maxLength":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid %s %q", key, value)
+			}
+			if key == "minLength" {
+				f.minLength = &n
+			} else {
+				f.maxLength = &n
+			}
+		case "pattern":
+			re, err := regexp.Compile(value)
+			if err != nil {
+				return fmt.Errorf("invalid pattern: %w", err)
+			}
+			f.pattern = re
+		default:
+			return fmt.Errorf("unknown neuro tag option %q", key)
+		}
+	}
+	return nil
+}
+
+// fieldsSchema builds the action schema for params fields
+func fieldsSchema(fields []paramField) *ActionSchema {
+	properties := make(map[string]interface{}, len(fields))
+	var required []string
+	for _, f := range fields {
+		p := map[string]interface{}{"type": f.kind}
+		if f.items != "" {
+			p["items"] = map[string]interface{}{"type": f.items}
+		}
+		if f.enum != nil {
+			p["enum"] = f.enum
+		}
+		if f.min != nil {
+			p["minimum"] = *f.min
+		}
+		if f.max != nil {
+			p["maximum"] = *f.max
+		}
+		if f.minLength != nil {
+			p["minLength"] = *f.minLength
+		}
+		if f.maxLength != nil {
+			p["maxLength"] = *f.maxLength
+		}
+		if f.pattern != nil {
+			p["pattern"] = f.pattern.String()
+		}
+		properties[f.name] = p
+		if f.required {
+			required = append(required, f.name)
+		}
+	}
+	return WrapSchema(properties, required)
+}
+
+// check validates a raw parameter against the field constraints,
+// returning a failure message for Neuro or "" if it is valid
+func (f *paramField) check(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		if f.required {
+			return fmt.Sprintf("Missing required parameter %s", f.name)
+		}
+		return ""
+	}
+
+	if f.kind == "string" {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return fmt.Sprintf("Parameter %s must be a string", f.name)
+		}
+		if f.enum != nil && !containsString(f.enum, s) {
+			return fmt.Sprintf("Parameter %s must be one of: %s", f.name, strings.Join(f.enum, ", "))
+		}
+		length := utf8.RuneCountInString(s)
+		if f.minLength != nil && length < *f.minLength {
+			return fmt.Sprintf("Parameter %s must be at least %d characters", f.name, *f.minLength)
+		}
+		if f.maxLength != nil && length > *f.maxLength {
+			return fmt.Sprintf("Parameter %s must be at most %d characters", f.name, *f.maxLength)
+		}
+		if f.pattern != nil && !f.pattern.MatchString(s) {
+			return fmt.Sprintf("Parameter %s must match %s", f.name, f.pattern)
+		}
+	}
+
+	if f.kind == "integer" || f.kind == "number" {
+		var n float64
+		if err := json.Unmarshal(raw, &n); err != nil {
+			return fmt.Sprintf("Parameter %s must be a number", f.name)
+		}
+		if f.min != nil && n < *f.min {
+			return fmt.Sprintf("Parameter %s must be at least %v", f.name, *f.min)
+		}
+		if f.max != nil && n > *f.max {
+			return fmt.Sprintf("Parameter %s must be at most %v", f.name, *f.max)
+		}
+	}
+
+	return ""
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// snakeCase converts a Go identifier such as GiveItem or HTTPGet to give_item or http_get
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
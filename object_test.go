@@ -0,0 +1,239 @@
+package neuro
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestSnakeCase(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Jump", "jump"},
+		{"GiveItem", "give_item"},
+		{"OpenHTTPPort", "open_http_port"},
+		{"HTTP", "http"},
+		{"UseSlot2", "use_slot2"},
+		{"ID", "id"},
+		{"already_snake", "already_snake"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := snakeCase(tt.in); got != tt.want {
+			t.Errorf("snakeCase(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+type giveItemParams struct {
+	Item     string `json:"item" neuro:"required,enum=sword|shield"`
+	Quantity int    `json:"quantity" neuro:"min=1,max=99"`
+}
+
+// testShop records which action methods ran
+type testShop struct {
+	given  []giveItemParams
+	closed bool
+	stock  int
+}
+
+func (s *testShop) ActionGiveItem(p giveItemParams) error {
+	s.given = append(s.given, p)
+	return nil
+}
+
+func (s *testShop) ValidateGiveItem(p giveItemParams) error {
+	if p.Quantity > s.stock {
+		return errors.New("Not enough in stock")
+	}
+	return nil
+}
+
+func (s *testShop) ActionCloseShop() {
+	s.closed = true
+}
+
+func (s *testShop) Helper() {}
+
+func TestObjectActions(t *testing.T) {
+	handlers, err := ObjectActions(&testShop{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, len(handlers))
+	for i, h := range handlers {
+		names[i] = h.GetName()
+	}
+	if want := []string{"close_shop", "give_item"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("action names = %v, want %v", names, want)
+	}
+
+	schema := handlers[1].GetSchema()
+	if !reflect.DeepEqual(schema.Required, []string{"item"}) {
+		t.Errorf("required = %v, want [item]", schema.Required)
+	}
+	if _, ok := schema.Properties["quantity"]; !ok {
+		t.Errorf("schema has no quantity property: %+v", schema.Properties)
+	}
+}
+
+func TestObjectActionValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		success bool
+		message string
+	}{
+		{"valid", `{"item": "sword", "quantity": 2}`, true, ""},
+		{"missing required", `{"quantity": 2}`, false, "Missing required parameter item"},
+		{"outside enum", `{"item": "bow"}`, false, "Parameter item must be one of: sword, shield"},
+		{"below min", `{"item": "sword", "quantity": 0}`, false, "Parameter quantity must be at least 1"},
+		{"above max", `{"item": "sword", "quantity": 100}`, false, "Parameter quantity must be at most 99"},
+		{"wrong type", `{"item": 5}`, false, "Parameter item must be a string"},
+		{"not an object", `[1]`, false, "Parameters must be a JSON object"},
+		{"Validate method fails", `{"item": "sword", "quantity": 5}`, false, "Not enough in stock"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shop := &testShop{stock: 3}
+			handlers, err := ObjectActions(shop)
+			if err != nil {
+				t.Fatal(err)
+			}
+			give := handlers[1]
+
+			state, result := give.Validate(json.RawMessage(tt.data))
+			if result.Successful != tt.success || result.Message != tt.message {
+				t.Fatalf("Validate() = %+v, want success %v with %q", result, tt.success, tt.message)
+			}
+			// The method only runs from Execute, once the result was sent
+			if len(shop.given) != 0 {
+				t.Fatal("Validate() ran the action method")
+			}
+			if !tt.success {
+				return
+			}
+
+			give.Execute(state)
+			want := []giveItemParams{{Item: "sword", Quantity: 2}}
+			if !reflect.DeepEqual(shop.given, want) {
+				t.Errorf("method got %+v, want %+v", shop.given, want)
+			}
+		})
+	}
+}
+
+func TestObjectActionWithoutParams(t *testing.T) {
+	shop := &testShop{}
+	handlers, err := ObjectActions(shop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closeShop := handlers[0]
+
+	if closeShop.GetSchema() != nil {
+		t.Errorf("schema = %+v, want none", closeShop.GetSchema())
+	}
+	state, result := closeShop.Validate(nil)
+	if !result.Successful || shop.closed {
+		t.Fatalf("Validate() = %+v, closed %v", result, shop.closed)
+	}
+	closeShop.Execute(state)
+	if !shop.closed {
+		t.Error("Execute() did not run the method")
+	}
+}
+
+type badParams struct{}
+
+func (badParams) ActionJump(a, b int) {}
+
+type noActions struct{}
+
+func (noActions) Jump() {}
+
+func TestObjectActionsErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  interface{}
+	}{
+		{"nil", nil},
+		{"no action methods", noActions{}},
+		{"too many arguments", badParams{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ObjectActions(tt.obj); err == nil {
+				t.Error("ObjectActions() succeeded, want error")
+			}
+		})
+	}
+}
+
+type itemParams struct {
+	Item string `json:"item" neuro:"required,enum=sword|shield"`
+}
+
+// Counts is exported so it can be embedded through a pointer
+type Counts struct {
+	Quantity int    `json:"quantity" neuro:"min=1"`
+	Note     string `json:"note"`
+}
+
+type embeddedParams struct {
+	itemParams
+	*Counts
+	Note  string     `json:"note"`
+	Extra itemParams `json:"extra"`
+}
+
+type embeddedShop struct {
+	got embeddedParams
+}
+
+func (s *embeddedShop) ActionBuy(p embeddedParams) { s.got = p }
+
+func TestObjectActionEmbeddedParams(t *testing.T) {
+	shop := &embeddedShop{}
+	handlers, err := ObjectActions(shop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := handlers[0]
+
+	// Embedded fields are flattened and the outer note hides the embedded one
+	schema := h.GetSchema()
+	var names []string
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if want := []string{"extra", "item", "note", "quantity"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("properties = %v, want %v", names, want)
+	}
+	if !reflect.DeepEqual(schema.Required, []string{"item"}) {
+		t.Errorf("required = %v, want [item]", schema.Required)
+	}
+	if kind := schema.Properties["extra"].(map[string]interface{})["type"]; kind != "object" {
+		t.Errorf("tagged embedded-type field has type %v, want object", kind)
+	}
+
+	if _, result := h.Validate(json.RawMessage(`{"item": "axe"}`)); result.Successful {
+		t.Error("Validate() accepted an embedded field outside its enum")
+	}
+	state, result := h.Validate(json.RawMessage(`{"item": "sword", "quantity": 2, "note": "gift"}`))
+	if !result.Successful {
+		t.Fatalf("Validate() = %+v", result)
+	}
+	h.Execute(state)
+	if shop.got.Item != "sword" || shop.got.Counts == nil || shop.got.Quantity != 2 || shop.got.Note != "gift" {
+		t.Errorf("method got %+v", shop.got)
+	}
+}
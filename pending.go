@@ -0,0 +1,203 @@
+package neuro
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Outstanding Actions
+
+// DefaultActionResultTimeout is how long an action may stay without a result
+// before the client automatically fails it
+const DefaultActionResultTimeout = 30 * time.Second
+
+// resolvedRetention is how long resolved action IDs are remembered for deduplication
+const resolvedRetention = 10 * time.Minute
+
+var (
+	// ErrUnknownActionID is returned when sending a result for an ID Neuro never issued
+	ErrUnknownActionID = errors.New("unknown action ID")
+	// ErrResultAlreadySent is returned when sending a second result for the same action ID
+	ErrResultAlreadySent = errors.New("action result already sent")
+)
+
+// PendingAction is an action received from Neuro that has not been answered with a result yet
+type PendingAction struct {
+	ID       string
+	Name     string
+	Received time.Time
+	// Deadline is when the action will be failed automatically (zero if it never expires)
+	Deadline time.Time
+}
+
+// pendingTable tracks in-flight action IDs so each gets exactly one result
+type pendingTable struct {
+	mu        sync.Mutex
+	pending   map[string]*pendingEntry
+	resolved  map[string]time.Time
+	lastPrune time.Time
+}
+
+type pendingEntry struct {
+	action   PendingAction
+	timer    *time.Timer
+	onExpire func()
+	// sending is set while a result for the action is being sent
+	sending bool
+}
+
+func newPendingTable() *pendingTable {
+	return &pendingTable{
+		pending:  make(map[string]*pendingEntry),
+		resolved: make(map[string]time.Time),
+	}
+}
+
+// begin starts tracking an action. It returns false if the ID is already
+// in flight or was answered recently, in which case the action must be ignored.
+// onExpire is called once if no result is sent before the timeout.
+func (t *pendingTable) begin(action IncomingAction, timeout time.Duration, onExpire func()) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.prune(now)
+
+	if _, ok := t.pending[action.ID]; ok {
+		return false
+	}
+	if _, ok := t.resolved[action.ID]; ok {
+		return false
+	}
+
+	entry := &pendingEntry{
+		action: PendingAction{
+			ID:       action.ID,
+			Name:     action.Name,
+			Received: now,
+		},
+		onExpire: onExpire,
+	}
+	if timeout > 0 {
+		entry.action.Deadline = now.Add(timeout)
+		entry.timer = time.AfterFunc(timeout, onExpire)
+	}
+	t.pending[action.ID] = entry
+
+	return true
+}
+
+// claim reserves an action for sending its result and stops its expiry timer.
+// Only one claim for a pending ID succeeds until it is released.
+func (t *pendingTable) claim(id string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.pending[id]
+	if !ok {
+		if _, done := t.resolved[id]; done {
+			return ErrResultAlreadySent
+		}
+		return ErrUnknownActionID
+	}
+	if entry.sending {
+		return ErrResultAlreadySent
+	}
+
+	entry.sending = true
+	if entry.timer != nil {
+		entry.timer.Stop()
+		entry.timer = nil
+	}
+	return nil
+}
+
+// complete marks a claimed action as answered once its result was sent
+func (t *pendingTable) complete(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.pending, id)
+	t.resolved[id] = time.Now()
+}
+
+// release returns a claimed action to pending after its result could not be sent,
+// re-arming the expiry timer if the deadline has not passed yet
+func (t *pendingTable) release(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, ok := t.pending[id]
+	if !ok {
+		return
+	}
+	entry.sending = false
+	if entry.action.Deadline.IsZero() {
+		return
+	}
+	if remaining := time.Until(entry.action.Deadline); remaining > 0 {
+		entry.timer = time.AfterFunc(remaining, entry.onExpire)
+	}
+}
+
+// reset forgets every pending action, e.g. when a new connection is made,
+// so results and expiries for the old connection are not sent on the new one
+func (t *pendingTable) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for id, entry := range t.pending {
+		if entry.timer != nil {
+			entry.timer.Stop()
+		}
+		delete(t.pending, id)
+	}
+}
+
+// list returns all pending actions, oldest first
+func (t *pendingTable) list() []PendingAction {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	actions := make([]PendingAction, 0, len(t.pending))
+	for _, entry := range t.pending {
+		actions = append(actions, entry.action)
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		return actions[i].Received.Before(actions[j].Received)
+	})
+	return actions
+}
+
+// prune forgets resolved IDs older than the retention period. Must be called with t.mu held.
+func (t *pendingTable) prune(now time.Time) {
+	if now.Sub(t.lastPrune) < time.Minute {
+		return
+	}
+	t.lastPrune = now
+
+	for id, at := range t.resolved {
+		if now.Sub(at) > resolvedRetention {
+			delete(t.resolved, id)
+		}
+	}
+}
+
+// PendingActions returns the actions Neuro sent that have not received a result yet, oldest first
+func (c *Client) PendingActions() []PendingAction {
+	return c.pending.list()
+}
+
+// actionResultTimeout returns the configured result deadline (zero disables it)
+func (c *Client) actionResultTimeout() time.Duration {
+	switch {
+	case c.config.ActionResultTimeout < 0:
+		return 0
+	case c.config.ActionResultTimeout == 0:
+		return DefaultActionResultTimeout
+	default:
+		return c.config.ActionResultTimeout
+	}
+}
@@ -0,0 +1,173 @@
+package neuro
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPendingTableBegin(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(p *pendingTable)
+		want  bool
+	}{
+		{"new ID", func(p *pendingTable) {}, true},
+		{"in flight", func(p *pendingTable) {
+			p.begin(IncomingAction{ID: "1"}, 0, nil)
+		}, false},
+		{"being sent", func(p *pendingTable) {
+			p.begin(IncomingAction{ID: "1"}, 0, nil)
+			p.claim("1")
+		}, false},
+		{"answered", func(p *pendingTable) {
+			p.begin(IncomingAction{ID: "1"}, 0, nil)
+			p.claim("1")
+			p.complete("1")
+		}, false},
+		{"released after a failed send", func(p *pendingTable) {
+			p.begin(IncomingAction{ID: "1"}, 0, nil)
+			p.claim("1")
+			p.release("1")
+		}, false},
+		{"answered before the retention period", func(p *pendingTable) {
+			p.resolved["1"] = time.Now().Add(-resolvedRetention - time.Minute)
+		}, true},
+		{"cleared by reset", func(p *pendingTable) {
+			p.begin(IncomingAction{ID: "1"}, 0, nil)
+			p.reset()
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPendingTable()
+			tt.setup(p)
+			if got := p.begin(IncomingAction{ID: "1", Name: "jump"}, 0, nil); got != tt.want {
+				t.Errorf("begin() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPendingTableClaim(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(p *pendingTable)
+		want  error
+	}{
+		{"pending", func(p *pendingTable) {
+			p.begin(IncomingAction{ID: "1"}, 0, nil)
+		}, nil},
+		{"unknown", func(p *pendingTable) {}, ErrUnknownActionID},
+		{"already claimed", func(p *pendingTable) {
+			p.begin(IncomingAction{ID: "1"}, 0, nil)
+			p.claim("1")
+		}, ErrResultAlreadySent},
+		{"completed", func(p *pendingTable) {
+			p.begin(IncomingAction{ID: "1"}, 0, nil)
+			p.claim("1")
+			p.complete("1")
+		}, ErrResultAlreadySent},
+		{"released", func(p *pendingTable) {
+			p.begin(IncomingAction{ID: "1"}, 0, nil)
+			p.claim("1")
+			p.release("1")
+		}, nil},
+		{"reset", func(p *pendingTable) {
+			p.begin(IncomingAction{ID: "1"}, 0, nil)
+			p.reset()
+		}, ErrUnknownActionID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPendingTable()
+			tt.setup(p)
+			if err := p.claim("1"); !errors.Is(err, tt.want) {
+				t.Errorf("claim() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPendingTableExpiry(t *testing.T) {
+	const timeout = 20 * time.Millisecond
+
+	tests := []struct {
+		name   string
+		then   func(p *pendingTable)
+		expire bool
+	}{
+		{"unanswered", func(p *pendingTable) {}, true},
+		{"claimed", func(p *pendingTable) { p.claim("1") }, false},
+		{"completed", func(p *pendingTable) {
+			p.claim("1")
+			p.complete("1")
+		}, false},
+		{"released", func(p *pendingTable) {
+			p.claim("1")
+			p.release("1")
+		}, true},
+		{"reset", func(p *pendingTable) { p.reset() }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newPendingTable()
+			expired := make(chan struct{}, 1)
+			p.begin(IncomingAction{ID: "1"}, timeout, func() { expired <- struct{}{} })
+			tt.then(p)
+
+			select {
+			case <-expired:
+				if !tt.expire {
+					t.Error("action expired, want no expiry")
+				}
+			case <-time.After(5 * timeout):
+				if tt.expire {
+					t.Error("action did not expire")
+				}
+			}
+		})
+	}
+}
+
+func TestPendingTableReleaseAfterDeadline(t *testing.T) {
+	p := newPendingTable()
+	expired := make(chan struct{}, 1)
+	p.begin(IncomingAction{ID: "1"}, 10*time.Millisecond, func() { expired <- struct{}{} })
+	p.claim("1")
+
+	// The deadline passed while sending, so the caller's retry is the last chance
+	time.Sleep(20 * time.Millisecond)
+	p.release("1")
+
+	select {
+	case <-expired:
+		t.Error("action expired after its deadline had passed")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if err := p.claim("1"); err != nil {
+		t.Errorf("claim() after release = %v, want nil", err)
+	}
+}
+
+func TestPendingTableList(t *testing.T) {
+	p := newPendingTable()
+	p.begin(IncomingAction{ID: "1", Name: "first"}, time.Minute, nil)
+	time.Sleep(time.Millisecond)
+	p.begin(IncomingAction{ID: "2", Name: "second"}, 0, nil)
+	t.Cleanup(p.reset)
+
+	list := p.list()
+	if len(list) != 2 || list[0].ID != "1" || list[1].ID != "2" {
+		t.Fatalf("list() = %+v, want 1 then 2", list)
+	}
+	if list[0].Deadline.IsZero() {
+		t.Error("action with a timeout has no deadline")
+	}
+	if !list[1].Deadline.IsZero() {
+		t.Error("action without a timeout has a deadline")
+	}
+}
@@ -0,0 +1,352 @@
+package neuro
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Prompts
+
+// promptAction is a temporary action that reports the first value it accepts.
+// The first valid answer is reserved in Validate, so a concurrent answer is
+// rejected rather than also told it succeeded.
+type promptAction struct {
+	name        string
+	description string
+	schema      *ActionSchema
+	validate    func(data json.RawMessage) (interface{}, ExecutionResult)
+
+	mu       sync.Mutex
+	accepted bool
+	done     chan interface{}
+}
+
+func (a *promptAction) GetName() string {
+	return a.name
+}
+
+func (a *promptAction) GetDescription() string {
+	return a.description
+}
+
+func (a *promptAction) GetSchema() *ActionSchema {
+	return a.schema
+}
+
+func (a *promptAction) Validate(data json.RawMessage) (interface{}, ExecutionResult) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.accepted {
+		return nil, NewFailureResult("This question was already answered")
+	}
+	state, result := a.validate(data)
+	a.accepted = result.Successful
+	return state, result
+}
+
+// Execute only runs once the result was sent to Neuro, so the answer is final
+func (a *promptAction) Execute(state interface{}) {
+	a.done <- state
+}
+
+// release lets the prompt be answered again when a reserved answer is not executed
+func (a *promptAction) release(interface{}) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.accepted = false
+}
+
+// prompt registers a temporary action, forces it and waits for the first valid
+// answer. The action is unregistered however the prompt finishes.
+func (c *Client) prompt(ctx context.Context, action *promptAction, query string, opts []ForceOption) (interface{}, error) {
+	action.done = make(chan interface{}, 1)
+
+	if err := c.registerPromptAction(action); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := c.unregisterOwnAction(action); err != nil {
+			c.logger.Warn("Failed to unregister prompt action", "action", action.name, "error", err)
+		}
+	}()
+
+	c.logger.Info("Forcing prompt", "action", action.name)
+	if err := c.ForceActions(query, []string{action.name}, opts...); err != nil {
+		return nil, fmt.Errorf("failed to force %q: %w", action.name, err)
+	}
+
+	select {
+	case value := <-action.done:
+		return value, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("no answer to %q: %w", query, ctx.Err())
+	}
+}
+
+// registerPromptAction registers a prompt's action under its name, or under the
+// name with the first free suffix (e.g. choose_2) if another action has it. The
+// name is picked and registered under one lock, so concurrent prompts cannot
+// replace each other's action.
+func (c *Client) registerPromptAction(action *promptAction) error {
+	c.actionsMu.Lock()
+	defer c.actionsMu.Unlock()
+
+	base := action.name
+	for n := 2; ; n++ {
+		if _, taken := c.actions[action.name]; !taken {
+			break
+		}
+		action.name = fmt.Sprintf("%s_%d", base, n)
+	}
+	return c.registerActionsLocked([]ActionHandler{action})
+}
+
+// unregisterOwnAction unregisters a handler if it is still the one registered under its name
+func (c *Client) unregisterOwnAction(handler ActionHandler) error {
+	c.actionsMu.RLock()
+	current, ok := c.actions[handler.GetName()]
+	c.actionsMu.RUnlock()
+
+	if !ok || current != handler {
+		return nil
+	}
+	return c.UnregisterActions([]string{handler.GetName()})
+}
+
+// Choices
+
+// ChooseActionName is the name of the temporary action registered by Choose.
+// Prompts running at the same time get a numbered name, e.g. choose_2.
+const ChooseActionName = "choose"
+
+// Choice is an option Neuro can pick in Choose
+type Choice struct {
+	// Name is what Neuro picks and must be unique among the options
+	Name string
+	// Description explains the option to Neuro (optional)
+	Description string
+}
+
+// Choose asks Neuro to pick one of the options and returns the chosen one.
+// A temporary action with an enum of the option names is registered and forced,
+// and unregistered once Neuro answers or ctx is done. Cancel ctx or give it a
+// deadline to stop waiting; the returned error then wraps ctx.Err().
+func (c *Client) Choose(ctx context.Context, query string, options []Choice, opts ...ForceOption) (Choice, error) {
+	if len(options) == 0 {
+		return Choice{}, errors.New("must specify at least one choice")
+	}
+
+	names := make([]string, len(options))
+	byName := make(map[string]Choice, len(options))
+	lines := []string{"Choose one of the following options:"}
+	for i, o := range options {
+		if o.Name == "" {
+			return Choice{}, errors.New("choice name cannot be empty")
+		}
+		if _, dup := byName[o.Name]; dup {
+			return Choice{}, fmt.Errorf("duplicate choice %q", o.Name)
+		}
+		names[i] = o.Name
+		byName[o.Name] = o
+		if o.Description != "" {
+			lines = append(lines, fmt.Sprintf("- %s: %s", o.Name, o.Description))
+		} else {
+			lines = append(lines, "- "+o.Name)
+		}
+	}
+
+	action := &promptAction{
+		name:        ChooseActionName,
+		description: strings.Join(lines, "\n"),
+		schema: WrapSchema(map[string]interface{}{
+			"choice": map[string]interface{}{
+				"type": "string",
+				"enum": names,
+			},
+		}, []string{"choice"}),
+		validate: func(data json.RawMessage) (interface{}, ExecutionResult) {
+			var params struct {
+				Choice string `json:"choice"`
+			}
+			if err := ParseActionData(data, &params); err != nil {
+				return nil, NewFailureResult("Invalid parameters")
+			}
+			choice, ok := byName[params.Choice]
+			if !ok {
+				return nil, NewFailureResult(fmt.Sprintf("%q is not an option. Options: %s", params.Choice, strings.Join(names, ", ")))
+			}
+			return choice, NewSuccessResult(fmt.Sprintf("Chose %s", choice.Name))
+		},
+	}
+
+	value, err := c.prompt(ctx, action, query, opts)
+	if err != nil {
+		return Choice{}, err
+	}
+	return value.(Choice), nil
+}
+
+// Text and Number Input
+
+const (
+	// AskTextActionName is the name of the temporary action registered by AskText
+	AskTextActionName = "answer_text"
+	// AskNumberActionName is the name of the temporary action registered by AskNumber
+	AskNumberActionName = "answer_number"
+)
+
+// TextOptions configures AskText
+type TextOptions struct {
+	// MinLength and MaxLength bound the answer length in characters (0 means unbounded)
+	MinLength int
+	MaxLength int
+	// Pattern is a regular expression the whole answer must match (optional)
+	Pattern string
+	// Filter rejects unwanted content. Its error message is sent back to Neuro.
+	Filter func(text string) error
+	// Description is the description of the temporary action (default "Answer with text")
+	Description string
+	// Force configures the action force
+	Force []ForceOption
+}
+
+// AskText asks Neuro for free text, such as a pet name or a sign, and returns the answer.
+// Leading and trailing whitespace is removed before the length, pattern and
+// filter checks, and empty answers are never accepted. Answers that fail a
+// check are rejected, so Neuro retries.
+func (c *Client) AskText(ctx context.Context, query string, opts TextOptions) (string, error) {
+	if opts.MinLength < 0 || opts.MaxLength < 0 || (opts.MaxLength > 0 && opts.MinLength > opts.MaxLength) {
+		return "", fmt.Errorf("invalid length range %d-%d", opts.MinLength, opts.MaxLength)
+	}
+
+	property := map[string]interface{}{"type": "string"}
+	if opts.MinLength > 0 {
+		property["minLength"] = opts.MinLength
+	}
+	if opts.MaxLength > 0 {
+		property["maxLength"] = opts.MaxLength
+	}
+
+	var pattern *regexp.Regexp
+	if opts.Pattern != "" {
+		// JSON schema patterns are unanchored, so the schema gets the same anchored expression
+		anchored := "^(?:" + opts.Pattern + ")$"
+		re, err := regexp.Compile(anchored)
+		if err != nil {
+			return "", fmt.Errorf("invalid pattern: %w", err)
+		}
+		pattern = re
+		property["pattern"] = anchored
+	}
+
+	description := opts.Description
+	if description == "" {
+		description = "Answer with text"
+	}
+
+	action := &promptAction{
+		name:        AskTextActionName,
+		description: description,
+		schema:      WrapSchema(map[string]interface{}{"text": property}, []string{"text"}),
+		validate: func(data json.RawMessage) (interface{}, ExecutionResult) {
+			var params struct {
+				Text *string `json:"text"`
+			}
+			if err := ParseActionData(data, &params); err != nil || params.Text == nil {
+				return nil, NewFailureResult("Missing text")
+			}
+
+			text := strings.TrimSpace(*params.Text)
+			length := utf8.RuneCountInString(text)
+			if length == 0 {
+				return nil, NewFailureResult("Text cannot be empty")
+			}
+			if length < opts.MinLength {
+				return nil, NewFailureResult(fmt.Sprintf("Text is too short (minimum %d characters)", opts.MinLength))
+			}
+			if opts.MaxLength > 0 && length > opts.MaxLength {
+				return nil, NewFailureResult(fmt.Sprintf("Text is too long (maximum %d characters)", opts.MaxLength))
+			}
+			if pattern != nil && !pattern.MatchString(text) {
+				return nil, NewFailureResult(fmt.Sprintf("Text must match the pattern %s", opts.Pattern))
+			}
+			if opts.Filter != nil {
+				if err := opts.Filter(text); err != nil {
+					return nil, NewFailureResult(err.Error())
+				}
+			}
+			return text, NewSuccessResult(fmt.Sprintf("Answered %q", text))
+		},
+	}
+
+	value, err := c.prompt(ctx, action, query, opts.Force)
+	if err != nil {
+		return "", err
+	}
+	return value.(string), nil
+}
+
+// BlockWords returns a TextOptions filter that rejects text containing any of
+// the words, ignoring case
+func BlockWords(words ...string) func(text string) error {
+	lowered := make([]string, len(words))
+	for i, w := range words {
+		lowered[i] = strings.ToLower(w)
+	}
+	return func(text string) error {
+		text = strings.ToLower(text)
+		for _, w := range lowered {
+			if w != "" && strings.Contains(text, w) {
+				return errors.New("Text contains a blocked word")
+			}
+		}
+		return nil
+	}
+}
+
+// AskNumber asks Neuro for a number between min and max (inclusive) and returns it.
+// Numbers outside the range are rejected, so Neuro retries.
+func (c *Client) AskNumber(ctx context.Context, query string, min, max float64, opts ...ForceOption) (float64, error) {
+	if math.IsNaN(min) || math.IsNaN(max) || math.IsInf(min, 0) || math.IsInf(max, 0) || min > max {
+		return 0, fmt.Errorf("invalid number range %v-%v", min, max)
+	}
+
+	action := &promptAction{
+		name:        AskNumberActionName,
+		description: fmt.Sprintf("Answer with a number from %v to %v", min, max),
+		schema: WrapSchema(map[string]interface{}{
+			"number": map[string]interface{}{
+				"type":    "number",
+				"minimum": min,
+				"maximum": max,
+			},
+		}, []string{"number"}),
+		validate: func(data json.RawMessage) (interface{}, ExecutionResult) {
+			var params struct {
+				Number *float64 `json:"number"`
+			}
+			if err := ParseActionData(data, &params); err != nil || params.Number == nil {
+				return nil, NewFailureResult("Missing number")
+			}
+			n := *params.Number
+			if n < min || n > max {
+				return nil, NewFailureResult(fmt.Sprintf("%v is out of range (%v to %v)", n, min, max))
+			}
+			return n, NewSuccessResult(fmt.Sprintf("Answered %v", n))
+		},
+	}
+
+	value, err := c.prompt(ctx, action, query, opts)
+	if err != nil {
+		return 0, err
+	}
+	return value.(float64), nil
+}
@@ -0,0 +1,219 @@
+package neuro
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+// promptResult is what a prompt running in the background returned
+type promptResult struct {
+	value interface{}
+	err   error
+}
+
+func TestChoose(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	done := make(chan promptResult, 1)
+	go func() {
+		choice, err := c.Choose(context.Background(), "Pick a door", []Choice{{Name: "left"}, {Name: "right"}})
+		done <- promptResult{choice, err}
+	}()
+	conn.expect(CommandRegisterActions)
+	conn.expect(CommandForceActions)
+
+	// An invalid answer is rejected so Neuro retries
+	conn.sendAction("1", ChooseActionName, `{"choice": "middle"}`)
+	if result := conn.expectResult(); result.Success {
+		t.Fatalf("invalid choice got %+v, want failure", result)
+	}
+
+	conn.sendAction("2", ChooseActionName, `{"choice": "right"}`)
+	if result := conn.expectResult(); !result.Success {
+		t.Fatalf("valid choice got %+v, want success", result)
+	}
+	conn.expect(CommandUnregisterActions)
+
+	got := <-done
+	if got.err != nil || got.value.(Choice).Name != "right" {
+		t.Fatalf("Choose() = %v, %v, want right", got.value, got.err)
+	}
+}
+
+func TestChooseWhileAnotherPromptIsOpen(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan promptResult, 1)
+	go func() {
+		choice, err := c.Choose(ctx, "First", []Choice{{Name: "a"}})
+		done <- promptResult{choice, err}
+	}()
+	conn.expect(CommandRegisterActions)
+	conn.expect(CommandForceActions)
+
+	// The open prompt keeps its action and the second one gets a numbered name
+	second := make(chan promptResult, 1)
+	go func() {
+		choice, err := c.Choose(context.Background(), "Second", []Choice{{Name: "b"}})
+		second <- promptResult{choice, err}
+	}()
+	var data RegisterActionsData
+	if err := json.Unmarshal(conn.expect(CommandRegisterActions).Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if name := data.Actions[0].Name; name != ChooseActionName+"_2" {
+		t.Fatalf("second prompt registered %q, want %s_2", name, ChooseActionName)
+	}
+	conn.expect(CommandForceActions)
+
+	conn.sendAction("1", ChooseActionName+"_2", `{"choice": "b"}`)
+	if result := conn.expectResult(); !result.Success {
+		t.Fatalf("second prompt got %+v, want success", result)
+	}
+	conn.expect(CommandUnregisterActions)
+	if got := <-second; got.err != nil || got.value.(Choice).Name != "b" {
+		t.Errorf("second Choose() = %v, %v, want b", got.value, got.err)
+	}
+
+	cancel()
+	if got := <-done; !errors.Is(got.err, context.Canceled) {
+		t.Errorf("first Choose() = %v, want %v", got.err, context.Canceled)
+	}
+	conn.expect(CommandUnregisterActions)
+}
+
+func TestPromptActionAcceptsOneAnswer(t *testing.T) {
+	action := &promptAction{
+		name:     "choose",
+		validate: func(data json.RawMessage) (interface{}, ExecutionResult) { return string(data), NewSuccessResult("") },
+		done:     make(chan interface{}, 1),
+	}
+
+	// The first valid answer is reserved, so a concurrent one is rejected
+	first, result := action.Validate(json.RawMessage(`"a"`))
+	if !result.Successful {
+		t.Fatalf("first Validate() = %+v", result)
+	}
+	if _, result := action.Validate(json.RawMessage(`"b"`)); result.Successful {
+		t.Fatal("second Validate() succeeded while the first answer was reserved")
+	}
+
+	// An answer that is not executed, e.g. because its result was not sent, is released
+	action.release(first)
+	second, result := action.Validate(json.RawMessage(`"b"`))
+	if !result.Successful {
+		t.Fatalf("Validate() after releasing = %+v", result)
+	}
+
+	action.Execute(second)
+	if got := <-action.done; got != `"b"` {
+		t.Errorf("answer = %v, want \"b\"", got)
+	}
+	if _, result := action.Validate(json.RawMessage(`"c"`)); result.Successful {
+		t.Error("Validate() accepted an answer after the prompt was answered")
+	}
+}
+
+func TestAskNumberRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		min, max float64
+	}{
+		{"min above max", 10, 1},
+		{"NaN min", math.NaN(), 1},
+		{"NaN max", 1, math.NaN()},
+		{"infinite min", math.Inf(-1), 1},
+		{"infinite max", 1, math.Inf(1)},
+	}
+
+	c, err := NewClient(ClientConfig{Game: "Test Game", WebsocketURL: "ws://localhost", LogHandler: discardLogs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.AskNumber(context.Background(), "How many?", tt.min, tt.max)
+			if want := fmt.Sprintf("invalid number range %v-%v", tt.min, tt.max); err == nil || err.Error() != want {
+				t.Errorf("AskNumber() = %v, want invalid range", err)
+			}
+		})
+	}
+}
+
+func TestAskNumber(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	done := make(chan promptResult, 1)
+	go func() {
+		n, err := c.AskNumber(context.Background(), "How many?", 1, 10)
+		done <- promptResult{n, err}
+	}()
+	conn.expect(CommandRegisterActions)
+	conn.expect(CommandForceActions)
+
+	for i, answer := range []string{`{"number": 11}`, `{"number": 0.5}`, `{}`} {
+		conn.sendAction(string(rune('a'+i)), AskNumberActionName, answer)
+		if result := conn.expectResult(); result.Success {
+			t.Fatalf("answer %s got %+v, want failure", answer, result)
+		}
+	}
+	conn.sendAction("ok", AskNumberActionName, `{"number": 7}`)
+	conn.expectResult()
+
+	select {
+	case got := <-done:
+		if got.err != nil || got.value.(float64) != 7 {
+			t.Fatalf("AskNumber() = %v, %v, want 7", got.value, got.err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("AskNumber did not return")
+	}
+}
+
+func TestAskTextPattern(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	done := make(chan promptResult, 1)
+	go func() {
+		text, err := c.AskText(context.Background(), "Name your pet", TextOptions{Pattern: "[a-z]+"})
+		done <- promptResult{text, err}
+	}()
+
+	// The schema carries the anchored pattern that answers are validated against
+	var data RegisterActionsData
+	if err := json.Unmarshal(conn.expect(CommandRegisterActions).Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	property := data.Actions[0].Schema.Properties["text"].(map[string]interface{})
+	if got := property["pattern"]; got != "^(?:[a-z]+)$" {
+		t.Errorf("schema pattern = %v, want the anchored pattern", got)
+	}
+	conn.expect(CommandForceActions)
+
+	conn.sendAction("1", AskTextActionName, `{"text": "rex1"}`)
+	if result := conn.expectResult(); result.Success {
+		t.Fatalf("partial match got %+v, want failure", result)
+	}
+	conn.sendAction("2", AskTextActionName, `{"text": " rex "}`)
+	if result := conn.expectResult(); !result.Success {
+		t.Fatalf("answer got %+v, want success", result)
+	}
+
+	if got := <-done; got.err != nil || got.value.(string) != "rex" {
+		t.Fatalf("AskText() = %v, %v, want rex", got.value, got.err)
+	}
+}
@@ -0,0 +1,61 @@
+package neuro
+
+// Protocol Commands
+
+// Commands sent by the game to Neuro
+const (
+	CommandStartup           = "startup"
+	CommandContext           = "context"
+	CommandRegisterActions   = "actions/register"
+	CommandUnregisterActions = "actions/unregister"
+	CommandForceActions      = "actions/force"
+	CommandActionResult      = "action/result"
+	CommandShutdownReady     = "shutdown/ready"
+)
+
+// Commands sent by Neuro to the game
+const (
+	CommandAction            = "action"
+	CommandReregisterAll     = "actions/reregister_all"
+	CommandGracefulShutdown  = "shutdown/graceful"
+	CommandImmediateShutdown = "shutdown/immediate"
+)
+
+// Message Payloads
+
+// ContextData is the data of a "context" message
+type ContextData struct {
+	Message string `json:"message"`
+	Silent  bool   `json:"silent"`
+}
+
+// RegisterActionsData is the data of an "actions/register" message
+type RegisterActionsData struct {
+	Actions []ActionDefinition `json:"actions"`
+}
+
+// UnregisterActionsData is the data of an "actions/unregister" message
+type UnregisterActionsData struct {
+	ActionNames []string `json:"action_names"`
+}
+
+// ForceActionsData is the data of an "actions/force" message
+type ForceActionsData struct {
+	State            string   `json:"state,omitempty"`
+	Query            string   `json:"query"`
+	EphemeralContext bool     `json:"ephemeral_context"`
+	Priority         Priority `json:"priority,omitempty"`
+	ActionNames      []string `json:"action_names"`
+}
+
+// ActionResultData is the data of an "action/result" message
+type ActionResultData struct {
+	ID      string `json:"id"`
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// GracefulShutdownData is the data of a "shutdown/graceful" message
+type GracefulShutdownData struct {
+	WantsShutdown bool `json:"wants_shutdown"`
+}
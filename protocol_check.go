@@ -0,0 +1,312 @@
+package neuro
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Protocol Checking
+
+// Violation describes a message that breaks the Neuro API protocol
+type Violation struct {
+	Direction Direction
+	Command   string
+	Message   string
+}
+
+func (v Violation) Error() string {
+	return fmt.Sprintf("%s %s: %s", v.Direction, v.Command, v.Message)
+}
+
+// ProtocolChecker follows both sides of a session and reports protocol violations.
+// It tracks which actions are registered and which action IDs Neuro has issued
+// and not yet received a result for.
+type ProtocolChecker struct {
+	mu         sync.Mutex
+	registered map[string]ActionDefinition
+	pending    map[string]string
+	// resolved holds when each answered ID got its result, pruned after resolvedRetention
+	resolved  map[string]time.Time
+	lastPrune time.Time
+}
+
+// NewProtocolChecker creates a checker with no registered actions
+func NewProtocolChecker() *ProtocolChecker {
+	return &ProtocolChecker{
+		registered: make(map[string]ActionDefinition),
+		pending:    make(map[string]string),
+		resolved:   make(map[string]time.Time),
+	}
+}
+
+// Check validates a message and records its effect on the tracked session state.
+// It returns every violation found; an empty result means the message is valid.
+// Use Check for messages that have already been sent, e.g. when observing traffic.
+func (p *ProtocolChecker) Check(direction Direction, msg Message) []Violation {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	violations, changes := p.check(direction, msg)
+	for _, apply := range changes {
+		apply()
+	}
+	return violations
+}
+
+// Admit validates a message that is about to be sent and records its effect
+// only if there are no violations, so a rejected message leaves the state untouched
+func (p *ProtocolChecker) Admit(direction Direction, msg Message) []Violation {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	violations, changes := p.check(direction, msg)
+	if len(violations) > 0 {
+		return violations
+	}
+	for _, apply := range changes {
+		apply()
+	}
+	return nil
+}
+
+// Prepare validates a message without recording it. Calling commit records its
+// effect on the session state, e.g. once the message was actually sent.
+func (p *ProtocolChecker) Prepare(direction Direction, msg Message) (violations []Violation, commit func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	violations, changes := p.check(direction, msg)
+	return violations, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		for _, apply := range changes {
+			apply()
+		}
+	}
+}
+
+// Reset forgets all registered actions and action IDs, e.g. for a new connection
+func (p *ProtocolChecker) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.registered = make(map[string]ActionDefinition)
+	p.pending = make(map[string]string)
+	p.resolved = make(map[string]time.Time)
+}
+
+// check must be called with p.mu held. State changes are returned rather than
+// applied so callers can decide whether to keep them.
+func (p *ProtocolChecker) check(direction Direction, msg Message) ([]Violation, []func()) {
+	var violations []Violation
+	report := func(format string, args ...interface{}) {
+		violations = append(violations, Violation{
+			Direction: direction,
+			Command:   msg.Command,
+			Message:   fmt.Sprintf(format, args...),
+		})
+	}
+
+	var changes []func()
+	change := func(apply func()) {
+		changes = append(changes, apply)
+	}
+
+	p.prune(time.Now())
+
+	if direction == DirectionOutbound {
+		p.checkOutbound(msg, report, change)
+	} else {
+		p.checkInbound(msg, report, change)
+	}
+
+	return violations, changes
+}
+
+// prune forgets resolved IDs older than the retention period. Must be called with p.mu held.
+func (p *ProtocolChecker) prune(now time.Time) {
+	if now.Sub(p.lastPrune) < time.Minute {
+		return
+	}
+	p.lastPrune = now
+
+	for id, at := range p.resolved {
+		if now.Sub(at) > resolvedRetention {
+			delete(p.resolved, id)
+		}
+	}
+}
+
+// Registered returns the names of all currently registered actions, sorted
+func (p *ProtocolChecker) Registered() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return sortedKeys(p.registered)
+}
+
+// IsRegistered reports whether an action is currently registered
+func (p *ProtocolChecker) IsRegistered(name string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.registered[name]
+	return ok
+}
+
+// Pending returns the IDs of actions that have not received a result, sorted
+func (p *ProtocolChecker) Pending() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return sortedKeys(p.pending)
+}
+
+func (p *ProtocolChecker) checkOutbound(msg Message, report func(string, ...interface{}), change func(func())) {
+	if msg.Game == "" {
+		report("missing game name")
+	}
+
+	switch msg.Command {
+	case CommandStartup:
+		// Startup clears all registered actions on Neuro's side
+		change(func() { p.registered = make(map[string]ActionDefinition) })
+
+	case CommandContext:
+		var data ContextData
+		if !decodeData(msg, &data, report) {
+			return
+		}
+		if data.Message == "" {
+			report("empty context message")
+		}
+
+	case CommandRegisterActions:
+		var data RegisterActionsData
+		if !decodeData(msg, &data, report) {
+			return
+		}
+		if len(data.Actions) == 0 {
+			report("no actions to register")
+		}
+		seen := make(map[string]bool, len(data.Actions))
+		for _, a := range data.Actions {
+			if a.Name == "" {
+				report("action with empty name")
+				continue
+			}
+			if seen[a.Name] {
+				report("action %q appears more than once in the same batch", a.Name)
+				continue
+			}
+			seen[a.Name] = true
+			if a.Schema != nil && a.Schema.Type != "object" {
+				report("action %q has schema type %q, must be \"object\"", a.Name, a.Schema.Type)
+			}
+			a := a
+			change(func() { p.registered[a.Name] = a })
+		}
+
+	case CommandUnregisterActions:
+		var data UnregisterActionsData
+		if !decodeData(msg, &data, report) {
+			return
+		}
+		for _, name := range data.ActionNames {
+			if _, exists := p.registered[name]; !exists {
+				report("action %q is not registered", name)
+			}
+			name := name
+			change(func() { delete(p.registered, name) })
+		}
+
+	case CommandForceActions:
+		var data ForceActionsData
+		if !decodeData(msg, &data, report) {
+			return
+		}
+		if data.Query == "" {
+			report("empty query")
+		}
+		if len(data.ActionNames) == 0 {
+			report("no action names")
+		}
+		for _, name := range data.ActionNames {
+			if _, exists := p.registered[name]; !exists {
+				report("forces unregistered action %q", name)
+			}
+		}
+		switch data.Priority {
+		case "", PriorityLow, PriorityMedium, PriorityHigh, PriorityCritical:
+		default:
+			report("unknown priority %q", data.Priority)
+		}
+
+	case CommandActionResult:
+		var data ActionResultData
+		if !decodeData(msg, &data, report) {
+			return
+		}
+		if _, ok := p.pending[data.ID]; ok {
+			change(func() {
+				delete(p.pending, data.ID)
+				p.resolved[data.ID] = time.Now()
+			})
+		} else if _, ok := p.resolved[data.ID]; ok {
+			report("duplicate result for action ID %q", data.ID)
+		} else {
+			report("result for unknown action ID %q", data.ID)
+		}
+
+	case CommandShutdownReady:
+
+	default:
+		report("unknown command")
+	}
+}
+
+func (p *ProtocolChecker) checkInbound(msg Message, report func(string, ...interface{}), change func(func())) {
+	switch msg.Command {
+	case CommandAction:
+		var action IncomingAction
+		if !decodeData(msg, &action, report) {
+			return
+		}
+		if action.ID == "" {
+			report("action without ID")
+			return
+		}
+		_, inFlight := p.pending[action.ID]
+		_, answered := p.resolved[action.ID]
+		if inFlight || answered {
+			report("duplicate action ID %q", action.ID)
+		}
+		if _, exists := p.registered[action.Name]; !exists {
+			report("action %q is not registered", action.Name)
+		}
+		if action.Data != "" && !json.Valid([]byte(action.Data)) {
+			report("action %q data is not valid JSON", action.Name)
+		}
+		change(func() { p.pending[action.ID] = action.Name })
+
+	case CommandReregisterAll:
+		// Neuro has forgotten all actions and expects them to be registered again
+		change(func() { p.registered = make(map[string]ActionDefinition) })
+
+	case CommandGracefulShutdown, CommandImmediateShutdown:
+
+	default:
+		report("unknown command")
+	}
+}
+
+func decodeData(msg Message, v interface{}, report func(string, ...interface{})) bool {
+	if len(msg.Data) == 0 {
+		report("missing data")
+		return false
+	}
+	if err := json.Unmarshal(msg.Data, v); err != nil {
+		report("invalid data: %v", err)
+		return false
+	}
+	return true
+}
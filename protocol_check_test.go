@@ -0,0 +1,209 @@
+package neuro
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// checkStep is a message fed to a ProtocolChecker
+type checkStep struct {
+	direction Direction
+	command   string
+	data      interface{}
+}
+
+func outbound(command string, data interface{}) checkStep {
+	return checkStep{DirectionOutbound, command, data}
+}
+
+func inbound(command string, data interface{}) checkStep {
+	return checkStep{DirectionInbound, command, data}
+}
+
+func (s checkStep) message(t *testing.T) Message {
+	t.Helper()
+
+	msg := Message{Command: s.command}
+	if s.direction == DirectionOutbound {
+		msg.Game = "Test Game"
+	}
+	if s.data != nil {
+		b, err := json.Marshal(s.data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg.Data = b
+	}
+	return msg
+}
+
+func registerStep(names ...string) checkStep {
+	actions := make([]ActionDefinition, len(names))
+	for i, name := range names {
+		actions[i] = ActionDefinition{Name: name, Description: name}
+	}
+	return outbound(CommandRegisterActions, RegisterActionsData{Actions: actions})
+}
+
+func TestProtocolChecker(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []checkStep // every step but the last must be valid
+		want  int         // violations of the last step
+	}{
+		{"register", []checkStep{registerStep("jump")}, 0},
+		{"register again in a later batch", []checkStep{registerStep("jump"), registerStep("jump")}, 0},
+		{"same name twice in a batch", []checkStep{registerStep("jump", "jump")}, 1},
+		{"empty register", []checkStep{outbound(CommandRegisterActions, RegisterActionsData{})}, 1},
+		{"schema not an object", []checkStep{outbound(CommandRegisterActions, RegisterActionsData{Actions: []ActionDefinition{
+			{Name: "jump", Schema: &ActionSchema{Type: "string"}},
+		}})}, 1},
+		{"unregister unknown", []checkStep{outbound(CommandUnregisterActions, UnregisterActionsData{ActionNames: []string{"jump"}})}, 1},
+		{"force registered", []checkStep{registerStep("jump"), outbound(CommandForceActions, ForceActionsData{Query: "q", ActionNames: []string{"jump"}})}, 0},
+		{"force unregistered", []checkStep{outbound(CommandForceActions, ForceActionsData{Query: "q", ActionNames: []string{"jump"}})}, 1},
+		{"force after startup cleared actions", []checkStep{
+			registerStep("jump"),
+			outbound(CommandStartup, nil),
+			outbound(CommandForceActions, ForceActionsData{Query: "q", ActionNames: []string{"jump"}}),
+		}, 1},
+		{"force after reregister_all", []checkStep{
+			registerStep("jump"),
+			inbound(CommandReregisterAll, nil),
+			outbound(CommandForceActions, ForceActionsData{Query: "q", ActionNames: []string{"jump"}}),
+		}, 1},
+		{"unknown priority", []checkStep{registerStep("jump"), outbound(CommandForceActions, ForceActionsData{Query: "q", ActionNames: []string{"jump"}, Priority: "urgent"})}, 1},
+		{"result for pending action", []checkStep{
+			registerStep("jump"),
+			inbound(CommandAction, IncomingAction{ID: "1", Name: "jump"}),
+			outbound(CommandActionResult, ActionResultData{ID: "1", Success: true}),
+		}, 0},
+		{"second result", []checkStep{
+			registerStep("jump"),
+			inbound(CommandAction, IncomingAction{ID: "1", Name: "jump"}),
+			outbound(CommandActionResult, ActionResultData{ID: "1", Success: true}),
+			outbound(CommandActionResult, ActionResultData{ID: "1", Success: true}),
+		}, 1},
+		{"result for unknown ID", []checkStep{outbound(CommandActionResult, ActionResultData{ID: "1"})}, 1},
+		{"duplicate action ID in flight", []checkStep{
+			registerStep("jump"),
+			inbound(CommandAction, IncomingAction{ID: "1", Name: "jump"}),
+			inbound(CommandAction, IncomingAction{ID: "1", Name: "jump"}),
+		}, 1},
+		{"duplicate action ID answered", []checkStep{
+			registerStep("jump"),
+			inbound(CommandAction, IncomingAction{ID: "1", Name: "jump"}),
+			outbound(CommandActionResult, ActionResultData{ID: "1", Success: true}),
+			inbound(CommandAction, IncomingAction{ID: "1", Name: "jump"}),
+		}, 1},
+		{"action not registered", []checkStep{inbound(CommandAction, IncomingAction{ID: "1", Name: "jump"})}, 1},
+		{"action data not JSON", []checkStep{registerStep("jump"), inbound(CommandAction, IncomingAction{ID: "1", Name: "jump", Data: "{"})}, 1},
+		{"unknown command", []checkStep{outbound("actions/forget", nil)}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewProtocolChecker()
+			last := len(tt.steps) - 1
+			for i, step := range tt.steps[:last] {
+				if v := p.Check(step.direction, step.message(t)); len(v) != 0 {
+					t.Fatalf("step %d: unexpected violations %v", i, v)
+				}
+			}
+			if v := p.Check(tt.steps[last].direction, tt.steps[last].message(t)); len(v) != tt.want {
+				t.Errorf("got %d violations %v, want %d", len(v), v, tt.want)
+			}
+		})
+	}
+}
+
+func TestProtocolCheckerAdmit(t *testing.T) {
+	p := NewProtocolChecker()
+
+	// A rejected message must not change the tracked state
+	if v := p.Admit(DirectionOutbound, registerStep("jump", "jump").message(t)); len(v) == 0 {
+		t.Fatal("Admit() accepted a batch with a duplicate name")
+	}
+	if p.IsRegistered("jump") {
+		t.Error("rejected registration was recorded")
+	}
+
+	if v := p.Admit(DirectionOutbound, registerStep("jump").message(t)); len(v) != 0 {
+		t.Fatalf("Admit() = %v, want no violations", v)
+	}
+	if !p.IsRegistered("jump") {
+		t.Error("admitted registration was not recorded")
+	}
+}
+
+func TestProtocolCheckerPrunesResolvedIDs(t *testing.T) {
+	p := NewProtocolChecker()
+	p.resolved["old"] = time.Now().Add(-resolvedRetention - time.Minute)
+	p.resolved["recent"] = time.Now()
+
+	p.Check(DirectionOutbound, registerStep("jump").message(t))
+
+	if _, ok := p.resolved["old"]; ok {
+		t.Error("old resolved ID was not pruned")
+	}
+	if _, ok := p.resolved["recent"]; !ok {
+		t.Error("recent resolved ID was pruned")
+	}
+}
+
+func TestProtocolCheckerPrepare(t *testing.T) {
+	p := NewProtocolChecker()
+	p.Check(DirectionOutbound, registerStep("jump").message(t))
+	p.Check(DirectionInbound, inbound(CommandAction, IncomingAction{ID: "1", Name: "jump"}).message(t))
+	result := outbound(CommandActionResult, ActionResultData{ID: "1", Success: true}).message(t)
+
+	// A result whose write failed is not committed, so it can be sent again
+	if v, _ := p.Prepare(DirectionOutbound, result); len(v) != 0 {
+		t.Fatalf("Prepare() = %v, want no violations", v)
+	}
+	v, commit := p.Prepare(DirectionOutbound, result)
+	if len(v) != 0 {
+		t.Fatalf("Prepare() again = %v, want no violations", v)
+	}
+
+	commit()
+	if v := p.Admit(DirectionOutbound, result); len(v) != 1 {
+		t.Errorf("Admit() after committing = %v, want a duplicate result", v)
+	}
+
+	p.Reset()
+	if len(p.Registered()) != 0 || len(p.Pending()) != 0 || len(p.resolved) != 0 {
+		t.Error("Reset() kept state from the previous session")
+	}
+}
+
+func TestConformanceResetOnReconnect(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, func(config *ClientConfig) {
+		config.Conformance = ConformanceStrict
+	})
+	conn := connect(t, c, s)
+
+	slow := &testAction{name: "slow", release: make(chan struct{})}
+	if err := c.RegisterAction(slow); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+	conn.sendAction("1", "slow", "")
+	waitFor(t, "action to be pending", func() bool { return len(c.conformance.Pending()) == 1 })
+
+	conn.conn.Close()
+	<-c.Errors()
+	conn = connect(t, c, s)
+	conn.expect(CommandRegisterActions)
+	if pending := c.conformance.Pending(); len(pending) != 0 {
+		t.Errorf("checker still tracks %v after reconnecting", pending)
+	}
+
+	// Neuro may reuse the ID on the new connection
+	close(slow.release)
+	conn.sendAction("1", "slow", "")
+	if result := conn.expectResult(); result.ID != "1" || !result.Success {
+		t.Errorf("got %+v, want success for 1", result)
+	}
+}
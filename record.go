@@ -0,0 +1,139 @@
+package neuro
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Session Recording
+
+// Direction describes which way a recorded message travelled
+type Direction string
+
+const (
+	// DirectionInbound is a message sent by Neuro to the game
+	DirectionInbound Direction = "inbound"
+	// DirectionOutbound is a message sent by the game to Neuro
+	DirectionOutbound Direction = "outbound"
+)
+
+// RecordedMessage is a single message captured by a Recorder
+type RecordedMessage struct {
+	Time      time.Time `json:"time"`
+	Direction Direction `json:"direction"`
+	Message   Message   `json:"message"`
+}
+
+// Recorder captures every inbound and outbound message of a session as JSONL.
+// Attach it to a client with ClientConfig.Recorder.
+type Recorder struct {
+	mu sync.Mutex
+	w  *bufio.Writer
+	// closer is the file opened by CreateRecorder (nil for NewRecorder)
+	closer io.Closer
+	enc    *json.Encoder
+	err    error
+}
+
+// NewRecorder creates a recorder that writes to w. Closing the recorder does
+// not close w, so it can be shared, e.g. os.Stdout.
+func NewRecorder(w io.Writer) *Recorder {
+	bw := bufio.NewWriter(w)
+	return &Recorder{
+		w:   bw,
+		enc: json.NewEncoder(bw),
+	}
+}
+
+// CreateRecorder creates (or truncates) a JSONL file and records into it.
+// Closing the recorder closes the file.
+func CreateRecorder(path string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %w", err)
+	}
+	r := NewRecorder(f)
+	r.closer = f
+	return r, nil
+}
+
+// Record appends a message to the recording
+func (r *Recorder) Record(direction Direction, msg Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+
+	if err := r.enc.Encode(RecordedMessage{
+		Time:      time.Now(),
+		Direction: direction,
+		Message:   msg,
+	}); err != nil {
+		r.err = fmt.Errorf("failed to write recording: %w", err)
+		return r.err
+	}
+
+	// Flush every message so a crashed session still leaves a usable recording
+	if err := r.w.Flush(); err != nil {
+		r.err = fmt.Errorf("failed to write recording: %w", err)
+		return r.err
+	}
+
+	return nil
+}
+
+// Close flushes the recording, and closes the file if it was created by CreateRecorder
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err == nil {
+		r.err = r.w.Flush()
+	}
+	if r.closer != nil {
+		if err := r.closer.Close(); err != nil && r.err == nil {
+			r.err = err
+		}
+		r.closer = nil
+	}
+
+	err := r.err
+	r.err = errors.New("recorder is closed")
+	return err
+}
+
+// ReadRecording parses a JSONL recording
+func ReadRecording(r io.Reader) ([]RecordedMessage, error) {
+	var messages []RecordedMessage
+
+	dec := json.NewDecoder(r)
+	for {
+		var m RecordedMessage
+		if err := dec.Decode(&m); err != nil {
+			if errors.Is(err, io.EOF) {
+				return messages, nil
+			}
+			return nil, fmt.Errorf("invalid recording entry %d: %w", len(messages)+1, err)
+		}
+		messages = append(messages, m)
+	}
+}
+
+// LoadRecording reads a JSONL recording from a file
+func LoadRecording(path string) ([]RecordedMessage, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open recording: %w", err)
+	}
+	defer f.Close()
+
+	return ReadRecording(f)
+}
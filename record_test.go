@@ -0,0 +1,267 @@
+package neuro
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// recordSession runs a short session against the test server and returns its recording
+func recordSession(t *testing.T) []RecordedMessage {
+	t.Helper()
+
+	var buf bytes.Buffer
+	recorder := NewRecorder(&buf)
+	s := newTestServer(t)
+	c := newTestClient(t, s, func(config *ClientConfig) {
+		config.Recorder = recorder
+	})
+	conn := connect(t, c, s)
+
+	if err := c.RegisterAction(&testAction{name: "jump"}); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+	if err := c.ForceActions("Jump!", []string{"jump"}); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandForceActions)
+	conn.sendAction("1", "jump", `{"height": 2}`)
+	conn.expectResult()
+
+	c.Close()
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	recording, err := ReadRecording(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return recording
+}
+
+func TestRecorderOrder(t *testing.T) {
+	recording := recordSession(t)
+
+	want := []struct {
+		direction Direction
+		command   string
+	}{
+		{DirectionOutbound, CommandStartup},
+		{DirectionOutbound, CommandRegisterActions},
+		{DirectionOutbound, CommandForceActions},
+		{DirectionInbound, CommandAction},
+		{DirectionOutbound, CommandActionResult},
+	}
+	if len(recording) != len(want) {
+		t.Fatalf("recorded %d messages, want %d: %+v", len(recording), len(want), recording)
+	}
+	for i, w := range want {
+		got := recording[i]
+		if got.Direction != w.direction || got.Message.Command != w.command {
+			t.Errorf("entry %d = %s %s, want %s %s", i, got.Direction, got.Message.Command, w.direction, w.command)
+		}
+		if got.Direction == DirectionOutbound && got.Message.Game != "Test Game" {
+			t.Errorf("entry %d has game %q, want the game name", i, got.Message.Game)
+		}
+		if i > 0 && got.Time.Before(recording[i-1].Time) {
+			t.Errorf("entry %d was recorded before entry %d", i, i-1)
+		}
+	}
+}
+
+func TestReplayRecordedSession(t *testing.T) {
+	recording := recordSession(t)
+
+	srv := NewReplayServer(recording, ReplayOptions{})
+	if err := srv.Start("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+
+	c, err := NewClient(ClientConfig{Game: "Test Game", WebsocketURL: srv.URL(), LogHandler: discardLogs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RegisterAction(&testAction{name: "jump"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ForceActions("Jump!", []string{"jump"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := srv.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplayReportsMismatch(t *testing.T) {
+	recording := recordSession(t)
+
+	srv := NewReplayServer(recording, ReplayOptions{OutboundTimeout: 200 * time.Millisecond})
+	if err := srv.Start("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+
+	c, err := NewClient(ClientConfig{Game: "Test Game", WebsocketURL: srv.URL(), LogHandler: discardLogs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	// The recorded session registered jump, not duck
+	if err := c.RegisterAction(&testAction{name: "duck"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	var replayErr *ReplayError
+	if err := srv.Wait(ctx); !errors.As(err, &replayErr) {
+		t.Fatalf("Wait() = %v, want a ReplayError", err)
+	}
+	if len(replayErr.Mismatches) == 0 || replayErr.Mismatches[0].Index != 1 {
+		t.Errorf("mismatches = %v, want the register at entry 1 first", replayErr.Mismatches)
+	}
+}
+
+func TestCompareMessages(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected Message
+		actual   Message
+		equal    bool
+	}{
+		{"same", Message{Command: "context", Data: json.RawMessage(`{"message":"hi"}`)},
+			Message{Command: "context", Data: json.RawMessage(`{"message":"hi"}`)}, true},
+		{"formatting and key order ignored", Message{Command: "context", Data: json.RawMessage(`{"message":"hi","silent":true}`)},
+			Message{Command: "context", Data: json.RawMessage(`{ "silent": true, "message": "hi" }`)}, true},
+		{"no data", Message{Command: "startup"}, Message{Command: "startup"}, true},
+		{"different command", Message{Command: "startup"}, Message{Command: "context"}, false},
+		{"different data", Message{Command: "context", Data: json.RawMessage(`{"message":"hi"}`)},
+			Message{Command: "context", Data: json.RawMessage(`{"message":"bye"}`)}, false},
+		{"missing data", Message{Command: "context", Data: json.RawMessage(`{"message":"hi"}`)},
+			Message{Command: "context"}, false},
+		{"invalid data", Message{Command: "context", Data: json.RawMessage(`{"message":"hi"}`)},
+			Message{Command: "context", Data: json.RawMessage(`{`)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CompareMessages(tt.expected, tt.actual)
+			if (err == nil) != tt.equal {
+				t.Errorf("CompareMessages() = %v, want equal %v", err, tt.equal)
+			}
+		})
+	}
+}
+
+func TestReplayReportsTrailingMessages(t *testing.T) {
+	recording := recordSession(t)
+
+	srv := NewReplayServer(recording, ReplayOptions{TrailingTimeout: time.Second})
+	if err := srv.Start("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { srv.Close() })
+
+	history := NewMemoryHistory(10)
+	c, err := NewClient(ClientConfig{Game: "Test Game", WebsocketURL: srv.URL(), LogHandler: discardLogs, History: history})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RegisterAction(&testAction{name: "jump"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.ForceActions("Jump!", []string{"jump"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The recording ends with the result, so anything sent after it is unexpected
+	waitFor(t, "action to be handled", func() bool {
+		records, _ := history.Query(HistoryQuery{Name: "jump"})
+		return len(records) == 1
+	})
+	if err := c.SendContext("extra", true); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	var replayErr *ReplayError
+	if err := srv.Wait(ctx); !errors.As(err, &replayErr) {
+		t.Fatalf("Wait() = %v, want a ReplayError", err)
+	}
+	m := replayErr.Mismatches
+	if len(m) != 1 || m[0].Index != len(recording) || m[0].Actual == nil || m[0].Actual.Command != CommandContext {
+		t.Errorf("mismatches = %v, want the trailing context", m)
+	}
+}
+
+// closeCounter is a writer that counts how often it was closed
+type closeCounter struct {
+	bytes.Buffer
+	closed int
+}
+
+func (w *closeCounter) Close() error {
+	w.closed++
+	return nil
+}
+
+func TestRecorderClose(t *testing.T) {
+	// A writer passed to NewRecorder belongs to the caller
+	var w closeCounter
+	recorder := NewRecorder(&w)
+	if err := recorder.Record(DirectionOutbound, Message{Command: CommandStartup}); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if w.closed != 0 {
+		t.Error("Close() closed the writer passed to NewRecorder")
+	}
+	if err := recorder.Record(DirectionOutbound, Message{Command: CommandStartup}); err == nil {
+		t.Error("Record() succeeded after Close()")
+	}
+
+	// A file created by CreateRecorder is closed with it
+	path := filepath.Join(t.TempDir(), "session.jsonl")
+	recorder, err := CreateRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Record(DirectionOutbound, Message{Command: CommandStartup}); err != nil {
+		t.Fatal(err)
+	}
+	file := recorder.closer.(*os.File)
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("file was not closed: closing it again = %v", err)
+	}
+	recording, err := LoadRecording(path)
+	if err != nil || len(recording) != 1 {
+		t.Errorf("LoadRecording() = %d entries, %v, want 1", len(recording), err)
+	}
+}
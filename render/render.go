@@ -0,0 +1,380 @@
+// Package render turns common game state into compact, LLM-friendly text
+// for use with neuro.WithState and Client.SendContext
+package render
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Format selects the output style
+type Format int
+
+const (
+	// Text renders plain, column-aligned text
+	Text Format = iota
+	// Markdown renders markdown tables and lists
+	Markdown
+)
+
+// Tables
+
+// Table renders rows under a header row
+func Table(headers []string, rows [][]string, format Format) string {
+	if format == Markdown {
+		return markdownTable(headers, rows)
+	}
+	return textTable(headers, rows)
+}
+
+func markdownTable(headers []string, rows [][]string) string {
+	var b strings.Builder
+
+	writeRow := func(cells []string) {
+		b.WriteString("|")
+		for i := range headers {
+			cell := ""
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			b.WriteString(" ")
+			b.WriteString(escapeMarkdown(cell))
+			b.WriteString(" |")
+		}
+		b.WriteString("\n")
+	}
+
+	writeRow(headers)
+	b.WriteString("|")
+	for range headers {
+		b.WriteString(" --- |")
+	}
+	b.WriteString("\n")
+	for _, row := range rows {
+		writeRow(row)
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func textTable(headers []string, rows [][]string) string {
+	cols := len(headers)
+	for _, row := range rows {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+
+	widths := make([]int, cols)
+	measure := func(cells []string) {
+		for i := range widths {
+			if i < len(cells) {
+				if w := utf8.RuneCountInString(cells[i]); w > widths[i] {
+					widths[i] = w
+				}
+			}
+		}
+	}
+	measure(headers)
+	for _, row := range rows {
+		measure(row)
+	}
+
+	var b strings.Builder
+	writeRow := func(cells []string) {
+		var line strings.Builder
+		for i, w := range widths {
+			cell := ""
+			if i < len(cells) {
+				cell = cells[i]
+			}
+			if i > 0 {
+				line.WriteString("  ")
+			}
+			line.WriteString(pad(cell, w))
+		}
+		b.WriteString(strings.TrimRight(line.String(), " "))
+		b.WriteString("\n")
+	}
+
+	if len(headers) > 0 {
+		writeRow(headers)
+	}
+	for _, row := range rows {
+		writeRow(row)
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Grids
+
+// CoordinateStyle decides how grid cells are named. The same names are used
+// when rendering and in Coordinate/Parse, so they can be used as action parameters.
+type CoordinateStyle int
+
+const (
+	// LetterNumber names columns A, B, C... and rows 1, 2, 3... ("B3")
+	LetterNumber CoordinateStyle = iota
+	// CellNumber numbers cells 1..n left to right, top to bottom ("5")
+	CellNumber
+	// RowColumn names cells by 1-based row and column ("3,2")
+	RowColumn
+)
+
+// Grid is a 2D board indexed as Cells[row][column]
+type Grid struct {
+	Cells [][]string
+	// Empty is shown for empty cells (default "."). With CellNumber, empty cells show their number instead.
+	Empty string
+	Style CoordinateStyle
+}
+
+// Coordinate returns the name of a cell in the grid's coordinate style
+func (g Grid) Coordinate(row, col int) string {
+	switch g.Style {
+	case CellNumber:
+		return strconv.Itoa(row*g.columns() + col + 1)
+	case RowColumn:
+		return fmt.Sprintf("%d,%d", row+1, col+1)
+	default:
+		return columnLetters(col) + strconv.Itoa(row+1)
+	}
+}
+
+// Parse converts a coordinate back to a row and column.
+// It reports false if the coordinate is malformed or outside the grid.
+func (g Grid) Parse(coordinate string) (row, col int, ok bool) {
+	coordinate = strings.TrimSpace(coordinate)
+
+	switch g.Style {
+	case CellNumber:
+		n, err := strconv.Atoi(coordinate)
+		cols := g.columns()
+		if err != nil || n < 1 || cols == 0 {
+			return 0, 0, false
+		}
+		row, col = (n-1)/cols, (n-1)%cols
+	case RowColumn:
+		r, c, found := strings.Cut(coordinate, ",")
+		if !found {
+			return 0, 0, false
+		}
+		ri, err1 := strconv.Atoi(strings.TrimSpace(r))
+		ci, err2 := strconv.Atoi(strings.TrimSpace(c))
+		if err1 != nil || err2 != nil {
+			return 0, 0, false
+		}
+		row, col = ri-1, ci-1
+	default:
+		upper := strings.ToUpper(coordinate)
+		i := 0
+		for i < len(upper) && upper[i] >= 'A' && upper[i] <= 'Z' {
+			i++
+		}
+		if i == 0 || i == len(upper) {
+			return 0, 0, false
+		}
+		r, err := strconv.Atoi(upper[i:])
+		if err != nil {
+			return 0, 0, false
+		}
+		col = 0
+		for _, ch := range upper[:i] {
+			col = col*26 + int(ch-'A'+1)
+		}
+		row, col = r-1, col-1
+	}
+
+	if row < 0 || row >= len(g.Cells) || col < 0 || col >= len(g.Cells[row]) {
+		return 0, 0, false
+	}
+	return row, col, true
+}
+
+// EmptyCoordinates lists the coordinates of empty cells, suitable for a schema enum
+func (g Grid) EmptyCoordinates() []string {
+	var coords []string
+	for r, row := range g.Cells {
+		for c, cell := range row {
+			if cell == "" {
+				coords = append(coords, g.Coordinate(r, c))
+			}
+		}
+	}
+	return coords
+}
+
+// Render draws the grid with labelled rows and columns
+func (g Grid) Render(format Format) string {
+	empty := g.Empty
+	if empty == "" {
+		empty = "."
+	}
+
+	cell := func(r, c int) string {
+		v := ""
+		if c < len(g.Cells[r]) {
+			v = g.Cells[r][c]
+		}
+		if v != "" {
+			return v
+		}
+		if g.Style == CellNumber {
+			return g.Coordinate(r, c)
+		}
+		return empty
+	}
+
+	cols := g.columns()
+
+	// Cell numbers already identify every cell, so no labels are needed
+	if g.Style == CellNumber {
+		rows := make([][]string, len(g.Cells))
+		for r := range g.Cells {
+			rows[r] = make([]string, cols)
+			for c := 0; c < cols; c++ {
+				rows[r][c] = cell(r, c)
+			}
+		}
+		if format == Markdown {
+			headers := make([]string, cols)
+			return markdownTable(headers, rows)
+		}
+		return textTable(nil, rows)
+	}
+
+	headers := make([]string, cols+1)
+	for c := 0; c < cols; c++ {
+		if g.Style == RowColumn {
+			headers[c+1] = strconv.Itoa(c + 1)
+		} else {
+			headers[c+1] = columnLetters(c)
+		}
+	}
+
+	rows := make([][]string, len(g.Cells))
+	for r := range g.Cells {
+		rows[r] = make([]string, cols+1)
+		rows[r][0] = strconv.Itoa(r + 1)
+		for c := 0; c < cols; c++ {
+			rows[r][c+1] = cell(r, c)
+		}
+	}
+
+	return Table(headers, rows, format)
+}
+
+func (g Grid) columns() int {
+	cols := 0
+	for _, row := range g.Cells {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+	return cols
+}
+
+// columnLetters names a 0-based column A..Z, AA, AB...
+func columnLetters(col int) string {
+	name := ""
+	for col >= 0 {
+		name = string(rune('A'+col%26)) + name
+		col = col/26 - 1
+	}
+	return name
+}
+
+// Lists
+
+// Hand renders cards as a numbered list. Numbers start at 1 and match
+// the 1-based index an action would use to pick a card.
+func Hand(cards []string, format Format) string {
+	if len(cards) == 0 {
+		return "(empty)"
+	}
+
+	var b strings.Builder
+	for i, card := range cards {
+		if format == Markdown {
+			card = escapeMarkdown(card)
+		}
+		fmt.Fprintf(&b, "%d. %s\n", i+1, card)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Item is an inventory entry
+type Item struct {
+	Name     string
+	Quantity int
+	// Note is optional extra information, e.g. "equipped"
+	Note string
+}
+
+// Inventory renders items with their quantities
+func Inventory(items []Item, format Format) string {
+	if len(items) == 0 {
+		return "(empty)"
+	}
+
+	hasNotes := false
+	for _, it := range items {
+		if it.Note != "" {
+			hasNotes = true
+			break
+		}
+	}
+
+	headers := []string{"Item", "Qty"}
+	if hasNotes {
+		headers = append(headers, "Note")
+	}
+
+	rows := make([][]string, len(items))
+	for i, it := range items {
+		rows[i] = []string{it.Name, strconv.Itoa(it.Quantity)}
+		if hasNotes {
+			rows[i] = append(rows[i], it.Note)
+		}
+	}
+
+	return Table(headers, rows, format)
+}
+
+// Stat is a named value, e.g. "HP" -> "12/20"
+type Stat struct {
+	Name  string
+	Value string
+}
+
+// Stats renders key/value pairs in the given order
+func Stats(stats []Stat, format Format) string {
+	if format == Markdown {
+		rows := make([][]string, len(stats))
+		for i, s := range stats {
+			rows[i] = []string{s.Name, s.Value}
+		}
+		return markdownTable([]string{"Stat", "Value"}, rows)
+	}
+
+	var b strings.Builder
+	for _, s := range stats {
+		fmt.Fprintf(&b, "%s: %s\n", s.Name, s.Value)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+func pad(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+func escapeMarkdown(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,112 @@
+package render
+
+import "testing"
+
+func TestGridParse(t *testing.T) {
+	board := [][]string{
+		{"", "", ""},
+		{"", "", ""},
+	}
+
+	tests := []struct {
+		name       string
+		style      CoordinateStyle
+		coordinate string
+		row, col   int
+		ok         bool
+	}{
+		{"letter number", LetterNumber, "B2", 1, 1, true},
+		{"letter number lowercase", LetterNumber, "c1", 0, 2, true},
+		{"letter number padded", LetterNumber, " A1 ", 0, 0, true},
+		{"letter number column outside", LetterNumber, "D1", 0, 0, false},
+		{"letter number row outside", LetterNumber, "A3", 0, 0, false},
+		{"letter number row zero", LetterNumber, "A0", 0, 0, false},
+		{"letter number no row", LetterNumber, "A", 0, 0, false},
+		{"letter number no column", LetterNumber, "1", 0, 0, false},
+		{"letter number trailing text", LetterNumber, "A1x", 0, 0, false},
+		{"cell number first", CellNumber, "1", 0, 0, true},
+		{"cell number second row", CellNumber, "5", 1, 1, true},
+		{"cell number last", CellNumber, "6", 1, 2, true},
+		{"cell number zero", CellNumber, "0", 0, 0, false},
+		{"cell number outside", CellNumber, "7", 0, 0, false},
+		{"cell number not a number", CellNumber, "B2", 0, 0, false},
+		{"row column", RowColumn, "2,3", 1, 2, true},
+		{"row column spaces", RowColumn, " 1 , 2 ", 0, 1, true},
+		{"row column outside", RowColumn, "3,1", 0, 0, false},
+		{"row column zero", RowColumn, "0,1", 0, 0, false},
+		{"row column no comma", RowColumn, "12", 0, 0, false},
+		{"row column not numbers", RowColumn, "a,b", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := Grid{Cells: board, Style: tt.style}
+			row, col, ok := g.Parse(tt.coordinate)
+			if ok != tt.ok {
+				t.Fatalf("Parse(%q) ok = %v, want %v", tt.coordinate, ok, tt.ok)
+			}
+			if ok && (row != tt.row || col != tt.col) {
+				t.Errorf("Parse(%q) = %d, %d, want %d, %d", tt.coordinate, row, col, tt.row, tt.col)
+			}
+		})
+	}
+}
+
+func TestGridCoordinateRoundTrip(t *testing.T) {
+	// 30 columns so letter names go past Z
+	row := make([]string, 30)
+	board := [][]string{row, row, row}
+
+	for _, style := range []CoordinateStyle{LetterNumber, CellNumber, RowColumn} {
+		g := Grid{Cells: board, Style: style}
+		for r := range board {
+			for c := range row {
+				coordinate := g.Coordinate(r, c)
+				gotRow, gotCol, ok := g.Parse(coordinate)
+				if !ok || gotRow != r || gotCol != c {
+					t.Errorf("style %d: Parse(Coordinate(%d, %d) = %q) = %d, %d, %v", style, r, c, coordinate, gotRow, gotCol, ok)
+				}
+			}
+		}
+	}
+}
+
+func TestColumnLetters(t *testing.T) {
+	tests := []struct {
+		col  int
+		want string
+	}{
+		{0, "A"},
+		{25, "Z"},
+		{26, "AA"},
+		{27, "AB"},
+		{51, "AZ"},
+		{52, "BA"},
+		{701, "ZZ"},
+		{702, "AAA"},
+	}
+
+	for _, tt := range tests {
+		if got := columnLetters(tt.col); got != tt.want {
+			t.Errorf("columnLetters(%d) = %q, want %q", tt.col, got, tt.want)
+		}
+	}
+}
+
+func TestGridEmptyCoordinates(t *testing.T) {
+	g := Grid{Cells: [][]string{
+		{"X", ""},
+		{"", "O"},
+	}}
+
+	got := g.EmptyCoordinates()
+	want := []string{"B1", "A2"}
+	if len(got) != len(want) {
+		t.Fatalf("EmptyCoordinates() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("EmptyCoordinates() = %v, want %v", got, want)
+		}
+	}
+}
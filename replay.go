@@ -0,0 +1,335 @@
+package neuro
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Session Replay
+
+// ReplayOptions configures a ReplayServer
+type ReplayOptions struct {
+	// Speed scales the original delays between messages.
+	// 1 replays in real time, 10 replays ten times faster and 0 sends inbound messages without delay.
+	Speed float64
+	// OutboundTimeout is how long to wait for each expected outbound message (default 5s)
+	OutboundTimeout time.Duration
+	// TrailingTimeout is how long to watch for unexpected outbound messages after the
+	// last recorded entry. Zero uses DefaultTrailingTimeout, negative disables it.
+	TrailingTimeout time.Duration
+	// IgnoreCommands lists outbound commands that are neither expected nor compared
+	IgnoreCommands []string
+	// Compare checks an outbound message against the recorded one.
+	// The default requires the same command and semantically equal data.
+	Compare func(expected, actual Message) error
+}
+
+// DefaultTrailingTimeout is how long a replay watches for outbound messages the recording does not have
+const DefaultTrailingTimeout = 200 * time.Millisecond
+
+// ReplayMismatch describes an outbound message that did not match the recording
+type ReplayMismatch struct {
+	// Index is the position of the expected message in the recording. A message
+	// sent after the last entry has the length of the recording as its index
+	// and an empty Expected message.
+	Index    int
+	Expected Message
+	Actual   *Message
+	Err      error
+}
+
+func (m ReplayMismatch) String() string {
+	if m.Expected.Command == "" && m.Actual != nil {
+		return fmt.Sprintf("entry %d: unexpected %s: %v", m.Index, m.Actual.Command, m.Err)
+	}
+	if m.Actual == nil {
+		return fmt.Sprintf("entry %d: expected %s: %v", m.Index, m.Expected.Command, m.Err)
+	}
+	return fmt.Sprintf("entry %d: expected %s, got %s: %v", m.Index, m.Expected.Command, m.Actual.Command, m.Err)
+}
+
+// ReplayError is returned by ReplayServer.Wait when outbound messages did not match
+type ReplayError struct {
+	Mismatches []ReplayMismatch
+}
+
+func (e *ReplayError) Error() string {
+	lines := make([]string, len(e.Mismatches))
+	for i, m := range e.Mismatches {
+		lines[i] = m.String()
+	}
+	return fmt.Sprintf("replay had %d mismatch(es):\n%s", len(e.Mismatches), strings.Join(lines, "\n"))
+}
+
+// ReplayServer stands in for Neuro and replays a recorded session against a client.
+// Inbound messages are sent back in their original order and (scaled) timing,
+// and each recorded outbound message is awaited and compared with what the client sends.
+type ReplayServer struct {
+	recording []RecordedMessage
+	opts      ReplayOptions
+	ignore    map[string]bool
+
+	listener net.Listener
+	server   *http.Server
+
+	mu         sync.Mutex
+	started    bool
+	mismatches []ReplayMismatch
+	done       chan struct{}
+	err        error
+}
+
+// NewReplayServer creates a replay server for a recording
+func NewReplayServer(recording []RecordedMessage, opts ReplayOptions) *ReplayServer {
+	if opts.OutboundTimeout <= 0 {
+		opts.OutboundTimeout = 5 * time.Second
+	}
+	if opts.TrailingTimeout == 0 {
+		opts.TrailingTimeout = DefaultTrailingTimeout
+	}
+	if opts.Compare == nil {
+		opts.Compare = CompareMessages
+	}
+
+	ignore := make(map[string]bool, len(opts.IgnoreCommands))
+	for _, cmd := range opts.IgnoreCommands {
+		ignore[cmd] = true
+	}
+
+	return &ReplayServer{
+		recording: recording,
+		opts:      opts,
+		ignore:    ignore,
+		done:      make(chan struct{}),
+	}
+}
+
+// Start listens on addr (e.g. "127.0.0.1:0") and serves the replay in the background
+func (s *ReplayServer) Start(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+
+	s.listener = l
+	s.server = &http.Server{Handler: s}
+	go s.server.Serve(l)
+
+	return nil
+}
+
+// URL returns the websocket URL to point a client at after Start
+func (s *ReplayServer) URL() string {
+	if s.listener == nil {
+		return ""
+	}
+	return "ws://" + s.listener.Addr().String()
+}
+
+// Close stops the server
+func (s *ReplayServer) Close() error {
+	if s.server == nil {
+		return nil
+	}
+	return s.server.Close()
+}
+
+// ServeHTTP accepts a single websocket connection and replays the session on it
+func (s *ReplayServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	if s.started {
+		s.mu.Unlock()
+		http.Error(w, "replay already in progress", http.StatusConflict)
+		return
+	}
+	s.started = true
+	s.mu.Unlock()
+
+	upgrader := websocket.Upgrader{CheckOrigin: func(*http.Request) bool { return true }}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		s.finish(fmt.Errorf("failed to upgrade connection: %w", err))
+		return
+	}
+	defer conn.Close()
+
+	s.finish(s.replay(conn))
+}
+
+// Wait blocks until the replay finishes and returns a *ReplayError if any outbound message did not match
+func (s *ReplayServer) Wait(ctx context.Context) error {
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	if len(s.mismatches) > 0 {
+		return &ReplayError{Mismatches: append([]ReplayMismatch(nil), s.mismatches...)}
+	}
+	return nil
+}
+
+// Mismatches returns the outbound mismatches found so far
+func (s *ReplayServer) Mismatches() []ReplayMismatch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ReplayMismatch(nil), s.mismatches...)
+}
+
+func (s *ReplayServer) finish(err error) {
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+	close(s.done)
+}
+
+func (s *ReplayServer) mismatch(m ReplayMismatch) {
+	s.mu.Lock()
+	s.mismatches = append(s.mismatches, m)
+	s.mu.Unlock()
+}
+
+func (s *ReplayServer) replay(conn *websocket.Conn) error {
+	var previous time.Time
+
+	for i, entry := range s.recording {
+		if !previous.IsZero() && s.opts.Speed > 0 && entry.Direction == DirectionInbound {
+			delay := time.Duration(float64(entry.Time.Sub(previous)) / s.opts.Speed)
+			if delay > 0 {
+				time.Sleep(delay)
+			}
+		}
+		previous = entry.Time
+
+		switch entry.Direction {
+		case DirectionInbound:
+			msgBytes, err := json.Marshal(entry.Message)
+			if err != nil {
+				return fmt.Errorf("entry %d: failed to marshal message: %w", i, err)
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, msgBytes); err != nil {
+				return fmt.Errorf("entry %d: failed to send message: %w", i, err)
+			}
+
+		case DirectionOutbound:
+			if s.ignore[entry.Message.Command] {
+				continue
+			}
+
+			actual, err := s.readOutbound(conn)
+			if err != nil {
+				s.mismatch(ReplayMismatch{Index: i, Expected: entry.Message, Err: err})
+				return nil
+			}
+			if err := s.opts.Compare(entry.Message, *actual); err != nil {
+				s.mismatch(ReplayMismatch{Index: i, Expected: entry.Message, Actual: actual, Err: err})
+			}
+
+		default:
+			return fmt.Errorf("entry %d: unknown direction %q", i, entry.Direction)
+		}
+	}
+
+	s.checkTrailing(conn)
+	return nil
+}
+
+// checkTrailing reports every message the client sends after the last recorded entry
+func (s *ReplayServer) checkTrailing(conn *websocket.Conn) {
+	if s.opts.TrailingTimeout < 0 {
+		return
+	}
+
+	deadline := time.Now().Add(s.opts.TrailingTimeout)
+	for {
+		// A read error, usually the deadline passing, ends the check
+		actual, err := s.readOutboundUntil(conn, deadline)
+		if err != nil {
+			return
+		}
+		s.mismatch(ReplayMismatch{
+			Index:  len(s.recording),
+			Actual: actual,
+			Err:    errors.New("message sent after the end of the recording"),
+		})
+	}
+}
+
+// readOutbound reads the next non-ignored message from the client
+func (s *ReplayServer) readOutbound(conn *websocket.Conn) (*Message, error) {
+	return s.readOutboundUntil(conn, time.Now().Add(s.opts.OutboundTimeout))
+}
+
+// readOutboundUntil reads the next non-ignored message from the client before the deadline
+func (s *ReplayServer) readOutboundUntil(conn *websocket.Conn, deadline time.Time) (*Message, error) {
+	conn.SetReadDeadline(deadline)
+	defer conn.SetReadDeadline(time.Time{})
+
+	for {
+		_, msgBytes, err := conn.ReadMessage()
+		if err != nil {
+			return nil, fmt.Errorf("no message received: %w", err)
+		}
+
+		var msg Message
+		if err := json.Unmarshal(msgBytes, &msg); err != nil {
+			return nil, fmt.Errorf("invalid message: %w", err)
+		}
+		if !s.ignore[msg.Command] {
+			return &msg, nil
+		}
+	}
+}
+
+// CompareMessages reports whether two messages have the same command and semantically equal data
+func CompareMessages(expected, actual Message) error {
+	if expected.Command != actual.Command {
+		return fmt.Errorf("command %q != %q", actual.Command, expected.Command)
+	}
+
+	equal, err := jsonEqual(expected.Data, actual.Data)
+	if err != nil {
+		return err
+	}
+	if !equal {
+		return fmt.Errorf("data %s != %s", actual.Data, expected.Data)
+	}
+
+	return nil
+}
+
+// jsonEqual compares two JSON documents ignoring formatting and key order
+func jsonEqual(a, b json.RawMessage) (bool, error) {
+	if len(a) == 0 || len(b) == 0 {
+		return len(bytes.TrimSpace(a)) == len(bytes.TrimSpace(b)), nil
+	}
+
+	var va, vb interface{}
+	if err := json.Unmarshal(a, &va); err != nil {
+		return false, errors.New("recorded data is not valid JSON")
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		return false, errors.New("sent data is not valid JSON")
+	}
+
+	ca, _ := json.Marshal(va)
+	cb, _ := json.Marshal(vb)
+	return bytes.Equal(ca, cb), nil
+}
@@ -0,0 +1,235 @@
+package neuro
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Scenes
+
+// Scene is a game mode (menu, combat, shop...) with its own set of actions.
+// Switching to a scene registers its actions and unregisters those of the
+// previous scene that it does not share.
+type Scene struct {
+	Name string
+	// Groups names action groups defined with DefineActionGroup
+	Groups []string
+	// Actions are extra handlers that belong only to this scene
+	Actions []ActionHandler
+	// Context is sent to Neuro after switching to the scene (optional)
+	Context string
+	// SilentContext sends Context as a silent context message
+	SilentContext bool
+}
+
+// sceneState holds the scene definitions and which scene is active
+type sceneState struct {
+	mu      sync.RWMutex
+	groups  map[string][]ActionHandler
+	scenes  map[string]Scene
+	defined map[string]bool
+	current string
+	active  map[string]ActionHandler
+	epoch   uint64
+}
+
+func newSceneState() *sceneState {
+	return &sceneState{
+		groups:  make(map[string][]ActionHandler),
+		scenes:  make(map[string]Scene),
+		defined: make(map[string]bool),
+		active:  make(map[string]ActionHandler),
+	}
+}
+
+// DefineActionGroup declares a named group of actions that scenes can include.
+// Redefining a group takes effect the next time a scene using it is switched to.
+func (c *Client) DefineActionGroup(name string, handlers ...ActionHandler) error {
+	if name == "" {
+		return errors.New("group name cannot be empty")
+	}
+	for _, h := range handlers {
+		if h.GetName() == "" {
+			return errors.New("action name cannot be empty")
+		}
+	}
+
+	s := c.scenes
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.groups[name] = append([]ActionHandler(nil), handlers...)
+	for _, h := range handlers {
+		s.defined[h.GetName()] = true
+	}
+	return nil
+}
+
+// DefineScene declares a scene. Its groups must already be defined.
+func (c *Client) DefineScene(scene Scene) error {
+	if scene.Name == "" {
+		return errors.New("scene name cannot be empty")
+	}
+
+	s := c.scenes
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.resolve(scene); err != nil {
+		return err
+	}
+
+	scene.Groups = append([]string(nil), scene.Groups...)
+	scene.Actions = append([]ActionHandler(nil), scene.Actions...)
+	s.scenes[scene.Name] = scene
+	for _, h := range scene.Actions {
+		s.defined[h.GetName()] = true
+	}
+	return nil
+}
+
+// CurrentScene returns the name of the active scene, or "" if none
+func (c *Client) CurrentScene() string {
+	c.scenes.mu.RLock()
+	defer c.scenes.mu.RUnlock()
+	return c.scenes.current
+}
+
+// SwitchScene makes the named scene active. Actions of the previous scene that
+// the new one does not share are unregistered, new ones are registered, and the
+// scene's context message is sent. Once SwitchScene returns, no action that
+// only belonged to the previous scene will be accepted, even if Neuro sent it
+// before the switch.
+//
+// Call SwitchScene from Execute rather than Validate: an action that switches
+// away from its own scene while validating is rejected as stale.
+func (c *Client) SwitchScene(name string) error {
+	s := c.scenes
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	scene, ok := s.scenes[name]
+	if !ok {
+		return fmt.Errorf("unknown scene %q", name)
+	}
+
+	next, err := s.resolve(scene)
+	if err != nil {
+		return err
+	}
+
+	var removed []string
+	var added []ActionHandler
+	for actionName, h := range s.active {
+		if nh, ok := next[actionName]; !ok || !sameValue(h, nh) {
+			removed = append(removed, actionName)
+		}
+	}
+	for actionName, h := range next {
+		if oh, ok := s.active[actionName]; !ok || !sameValue(oh, h) {
+			added = append(added, h)
+		}
+	}
+	sort.Strings(removed)
+	sort.Slice(added, func(i, j int) bool { return added[i].GetName() < added[j].GetName() })
+
+	c.logger.Info("Switching scene", "from", s.current, "to", name, "unregister", len(removed), "register", len(added))
+
+	if err := c.UnregisterActions(removed); err != nil {
+		return fmt.Errorf("failed to unregister actions of scene %q: %w", s.current, err)
+	}
+
+	if err := c.RegisterActions(added); err != nil {
+		// Put the previous scene's actions back so it stays fully active
+		restore := make([]ActionHandler, len(removed))
+		for i, actionName := range removed {
+			restore[i] = s.active[actionName]
+		}
+		if rerr := c.RegisterActions(restore); rerr != nil {
+			c.logger.Error("Failed to restore actions of scene", "scene", s.current, "error", rerr)
+		}
+		return fmt.Errorf("failed to register actions of scene %q: %w", name, err)
+	}
+
+	// Only a completed switch rejects in-flight actions of the previous scene
+	s.epoch++
+	s.current = name
+	s.active = next
+
+	if scene.Context != "" {
+		if err := c.SendContext(scene.Context, scene.SilentContext); err != nil {
+			return fmt.Errorf("failed to send scene context: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// resolve collects the handlers of a scene by name. Must be called with s.mu held.
+func (s *sceneState) resolve(scene Scene) (map[string]ActionHandler, error) {
+	handlers := make(map[string]ActionHandler)
+	add := func(h ActionHandler) error {
+		name := h.GetName()
+		if name == "" {
+			return errors.New("action name cannot be empty")
+		}
+		if existing, ok := handlers[name]; ok && !sameValue(existing, h) {
+			return fmt.Errorf("scene %q defines action %q more than once", scene.Name, name)
+		}
+		handlers[name] = h
+		return nil
+	}
+
+	for _, group := range scene.Groups {
+		hs, ok := s.groups[group]
+		if !ok {
+			return nil, fmt.Errorf("scene %q uses unknown action group %q", scene.Name, group)
+		}
+		for _, h := range hs {
+			if err := add(h); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, h := range scene.Actions {
+		if err := add(h); err != nil {
+			return nil, err
+		}
+	}
+
+	return handlers, nil
+}
+
+// currentEpoch returns the scene epoch to compare against once an action has been validated
+func (s *sceneState) currentEpoch() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.epoch
+}
+
+// accepts reports whether an action looked up at epoch is still valid.
+// Actions that no scene manages are always accepted. Must be called with s.mu held.
+func (s *sceneState) accepts(name string, epoch uint64) bool {
+	if epoch == s.epoch || !s.defined[name] {
+		return true
+	}
+	_, ok := s.active[name]
+	return ok
+}
+
+// sameValue reports whether two handlers or modules are the same value without
+// panicking on types that cannot be compared
+func sameValue(a, b interface{}) (same bool) {
+	if reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.TypeOf(a).Comparable() {
+		return false
+	}
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+	return a == b
+}
@@ -0,0 +1,143 @@
+package neuro
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// defineTestScenes defines a menu and a combat scene sharing the "pause" group
+func defineTestScenes(t *testing.T, c *Client) {
+	t.Helper()
+
+	if err := c.DefineActionGroup("pause", &testAction{name: "pause"}); err != nil {
+		t.Fatal(err)
+	}
+	scenes := []Scene{
+		{Name: "menu", Groups: []string{"pause"}, Actions: []ActionHandler{&testAction{name: "start"}}},
+		{Name: "combat", Groups: []string{"pause"}, Actions: []ActionHandler{&testAction{name: "attack"}, &testAction{name: "flee"}}, Context: "A fight starts"},
+	}
+	for _, scene := range scenes {
+		if err := c.DefineScene(scene); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSwitchScene(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+	defineTestScenes(t, c)
+
+	tests := []struct {
+		scene        string
+		unregistered []string
+		registered   []string
+		context      bool
+	}{
+		{"menu", nil, []string{"pause", "start"}, false},
+		{"combat", []string{"start"}, []string{"attack", "flee"}, true},
+		{"menu", []string{"attack", "flee"}, []string{"start"}, false},
+	}
+
+	for _, tt := range tests {
+		if err := c.SwitchScene(tt.scene); err != nil {
+			t.Fatalf("SwitchScene(%q) = %v", tt.scene, err)
+		}
+		if tt.unregistered != nil {
+			var data UnregisterActionsData
+			if err := json.Unmarshal(conn.expect(CommandUnregisterActions).Data, &data); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(data.ActionNames, tt.unregistered) {
+				t.Errorf("switching to %s unregistered %v, want %v", tt.scene, data.ActionNames, tt.unregistered)
+			}
+		}
+		var data RegisterActionsData
+		if err := json.Unmarshal(conn.expect(CommandRegisterActions).Data, &data); err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, a := range data.Actions {
+			names = append(names, a.Name)
+		}
+		if !reflect.DeepEqual(names, tt.registered) {
+			t.Errorf("switching to %s registered %v, want %v", tt.scene, names, tt.registered)
+		}
+		if tt.context {
+			conn.expect(CommandContext)
+		}
+		if got := c.CurrentScene(); got != tt.scene {
+			t.Errorf("CurrentScene() = %q, want %q", got, tt.scene)
+		}
+	}
+}
+
+func TestSwitchSceneFailureKeepsPreviousScene(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+	defineTestScenes(t, c)
+
+	if err := c.SwitchScene("menu"); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+	epoch := c.scenes.currentEpoch()
+
+	conn.conn.Close()
+	<-c.Errors()
+
+	if err := c.SwitchScene("combat"); err == nil {
+		t.Fatal("SwitchScene() succeeded without a connection")
+	}
+	if got := c.CurrentScene(); got != "menu" {
+		t.Errorf("CurrentScene() = %q after failing, want menu", got)
+	}
+	if c.scenes.currentEpoch() != epoch {
+		t.Error("a failed switch rejects actions of the previous scene")
+	}
+
+	// Reconnecting registers the menu again, and the switch can be retried
+	conn = connect(t, c, s)
+	conn.expect(CommandRegisterActions)
+	if err := c.SwitchScene("combat"); err != nil {
+		t.Fatalf("SwitchScene() after reconnecting = %v", err)
+	}
+	if got := c.CurrentScene(); got != "combat" {
+		t.Errorf("CurrentScene() = %q, want combat", got)
+	}
+}
+
+func TestSwitchSceneRejectsStaleActions(t *testing.T) {
+	s := newTestServer(t)
+	c := newTestClient(t, s, nil)
+	conn := connect(t, c, s)
+
+	start := &testAction{name: "start", release: make(chan struct{})}
+	if err := c.DefineScene(Scene{Name: "menu", Actions: []ActionHandler{start}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DefineScene(Scene{Name: "combat", Actions: []ActionHandler{&testAction{name: "attack"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SwitchScene("menu"); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+
+	// start is validating when the scene switches away from it
+	conn.sendAction("1", "start", "")
+	waitFor(t, "action to be pending", func() bool { return len(c.PendingActions()) == 1 })
+	if err := c.SwitchScene("combat"); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandUnregisterActions)
+	conn.expect(CommandRegisterActions)
+
+	close(start.release)
+	if result := conn.expectResult(); result.Success {
+		t.Errorf("stale action got %+v, want failure", result)
+	}
+}
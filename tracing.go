@@ -0,0 +1,241 @@
+package neuro
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Tracing
+
+// Attribute is a key/value pair attached to a span
+type Attribute struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+// Attr creates a span attribute
+func Attr(key string, value interface{}) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+// Tracer creates spans for the action round trip.
+// The shape mirrors the OpenTelemetry tracing API so an adapter is a thin wrapper:
+// the returned context must carry the new span so that spans started from it
+// become its children.
+type Tracer interface {
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span is a single timed operation within a trace
+type Span interface {
+	// SetAttributes adds or replaces attributes on the span
+	SetAttributes(attrs ...Attribute)
+	// AddEvent records a point-in-time event on the span
+	AddEvent(name string, attrs ...Attribute)
+	// SetStatus marks the span as succeeded or failed with a description
+	SetStatus(ok bool, description string)
+	// End completes the span. Calls after the first are ignored.
+	End()
+}
+
+// Span names used by the client
+const (
+	SpanForce    = "neuro.force"
+	SpanAction   = "neuro.action"
+	SpanValidate = "neuro.validate"
+	SpanExecute  = "neuro.execute"
+)
+
+// nopTracer creates spans that do nothing
+type nopTracer struct{}
+
+func (nopTracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttributes(...Attribute)    {}
+func (nopSpan) AddEvent(string, ...Attribute) {}
+func (nopSpan) SetStatus(bool, string)        {}
+func (nopSpan) End()                          {}
+
+// In-memory tracer
+
+// SpanEvent is an event recorded on a span
+type SpanEvent struct {
+	Name       string      `json:"name"`
+	Time       time.Time   `json:"time"`
+	Attributes []Attribute `json:"attributes,omitempty"`
+}
+
+// SpanData is a finished span recorded by InMemoryTracer
+type SpanData struct {
+	TraceID    string      `json:"trace_id"`
+	SpanID     string      `json:"span_id"`
+	ParentID   string      `json:"parent_id,omitempty"`
+	Name       string      `json:"name"`
+	Start      time.Time   `json:"start"`
+	End        time.Time   `json:"end"`
+	OK         bool        `json:"ok"`
+	Status     string      `json:"status,omitempty"`
+	Attributes []Attribute `json:"attributes,omitempty"`
+	Events     []SpanEvent `json:"events,omitempty"`
+}
+
+// Duration returns how long the span lasted
+func (s SpanData) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// InMemoryTracer records finished spans in memory.
+// It is intended for tests, local debugging and as an exporter stub.
+type InMemoryTracer struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+// NewInMemoryTracer creates an empty in-memory tracer
+func NewInMemoryTracer() *InMemoryTracer {
+	return &InMemoryTracer{}
+}
+
+type spanContextKey struct{}
+
+// Start implements Tracer
+func (t *InMemoryTracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	span := &memorySpan{
+		tracer: t,
+		data: SpanData{
+			SpanID:     randomHex(8),
+			Name:       name,
+			Start:      time.Now(),
+			OK:         true,
+			Attributes: append([]Attribute(nil), attrs...),
+		},
+	}
+
+	if parent, ok := ctx.Value(spanContextKey{}).(*memorySpan); ok {
+		span.data.TraceID = parent.data.TraceID
+		span.data.ParentID = parent.data.SpanID
+	} else {
+		span.data.TraceID = randomHex(16)
+	}
+
+	return context.WithValue(ctx, spanContextKey{}, span), span
+}
+
+// Spans returns a copy of all finished spans in the order they ended
+func (t *InMemoryTracer) Spans() []SpanData {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]SpanData(nil), t.spans...)
+}
+
+// Trace returns all finished spans belonging to a trace
+func (t *InMemoryTracer) Trace(traceID string) []SpanData {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var spans []SpanData
+	for _, s := range t.spans {
+		if s.TraceID == traceID {
+			spans = append(spans, s)
+		}
+	}
+	return spans
+}
+
+// Reset discards all recorded spans
+func (t *InMemoryTracer) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spans = nil
+}
+
+// WriteJSON writes every finished span as one JSON object per line
+func (t *InMemoryTracer) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, s := range t.Spans() {
+		if err := enc.Encode(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type memorySpan struct {
+	tracer *InMemoryTracer
+	mu     sync.Mutex
+	data   SpanData
+	ended  bool
+}
+
+func (s *memorySpan) SetAttributes(attrs ...Attribute) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range attrs {
+		replaced := false
+		for i := range s.data.Attributes {
+			if s.data.Attributes[i].Key == a.Key {
+				s.data.Attributes[i] = a
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			s.data.Attributes = append(s.data.Attributes, a)
+		}
+	}
+}
+
+func (s *memorySpan) AddEvent(name string, attrs ...Attribute) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Events = append(s.data.Events, SpanEvent{
+		Name:       name,
+		Time:       time.Now(),
+		Attributes: append([]Attribute(nil), attrs...),
+	})
+}
+
+func (s *memorySpan) SetStatus(ok bool, description string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.OK = ok
+	s.data.Status = description
+}
+
+func (s *memorySpan) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	s.tracer.mu.Lock()
+	s.tracer.spans = append(s.tracer.spans, data)
+	s.tracer.mu.Unlock()
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,199 @@
+package neuro
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// spansNamed returns the finished spans with the given name
+func spansNamed(tracer *InMemoryTracer, name string) []SpanData {
+	var spans []SpanData
+	for _, s := range tracer.Spans() {
+		if s.Name == name {
+			spans = append(spans, s)
+		}
+	}
+	return spans
+}
+
+// attribute returns the value of a span attribute, or nil if it is not set
+func attribute(span SpanData, key string) interface{} {
+	for _, a := range span.Attributes {
+		if a.Key == key {
+			return a.Value
+		}
+	}
+	return nil
+}
+
+func TestActionSpans(t *testing.T) {
+	tests := []struct {
+		name     string
+		action   string
+		data     string
+		ok       bool
+		status   string
+		children []string
+	}{
+		{"executed", "pick", `{"ok": true}`, true, "", []string{SpanValidate, SpanExecute}},
+		{"validation failed", "pick", `{"ok": false}`, false, "Not ok", []string{SpanValidate}},
+		{"unknown action", "missing", "", false, "unknown action", nil},
+		{"invalid data", "pick", "{", false, "invalid JSON in action data", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer := NewInMemoryTracer()
+			s := newTestServer(t)
+			c := newTestClient(t, s, func(config *ClientConfig) { config.Tracer = tracer })
+			conn := connect(t, c, s)
+			if err := c.RegisterAction(pickyAction{}); err != nil {
+				t.Fatal(err)
+			}
+			conn.expect(CommandRegisterActions)
+
+			conn.sendAction("1", tt.action, tt.data)
+			conn.expectResult()
+			waitFor(t, "action span", func() bool { return len(spansNamed(tracer, SpanAction)) == 1 })
+
+			action := spansNamed(tracer, SpanAction)[0]
+			if action.OK != tt.ok || action.Status != tt.status {
+				t.Errorf("action span status = %v %q, want %v %q", action.OK, action.Status, tt.ok, tt.status)
+			}
+			if action.ParentID != "" {
+				t.Errorf("unforced action span has parent %s", action.ParentID)
+			}
+			if got := attribute(action, "action.name"); got != tt.action {
+				t.Errorf("action.name = %v, want %s", got, tt.action)
+			}
+			if got := attribute(action, "action.forced"); got != false {
+				t.Errorf("action.forced = %v, want false", got)
+			}
+			if action.End.Before(action.Start) {
+				t.Error("action span ended before it started")
+			}
+
+			// Child spans end before the action span and belong to its trace
+			var children []string
+			for _, span := range tracer.Spans() {
+				if span.SpanID == action.SpanID {
+					break
+				}
+				if span.TraceID != action.TraceID || span.ParentID != action.SpanID {
+					t.Errorf("%s span is not a child of the action span", span.Name)
+				}
+				children = append(children, span.Name)
+			}
+			if strings.Join(children, ",") != strings.Join(tt.children, ",") {
+				t.Errorf("child spans = %v, want %v", children, tt.children)
+			}
+		})
+	}
+}
+
+func TestForceSpans(t *testing.T) {
+	tracer := NewInMemoryTracer()
+	s := newTestServer(t)
+	c := newTestClient(t, s, func(config *ClientConfig) { config.Tracer = tracer })
+	conn := connect(t, c, s)
+	if err := c.RegisterAction(pickyAction{}); err != nil {
+		t.Fatal(err)
+	}
+	conn.expect(CommandRegisterActions)
+
+	// A newer force ends the span of the previous one
+	if err := c.ForceActions("First", []string{"pick"}); err != nil {
+		t.Fatal(err)
+	}
+	readForce(t, conn)
+	if len(tracer.Spans()) != 0 {
+		t.Fatalf("spans = %v before anything ended", tracer.Spans())
+	}
+	if err := c.ForceActions("Second", []string{"pick"}); err != nil {
+		t.Fatal(err)
+	}
+	readForce(t, conn)
+	superseded := spansNamed(tracer, SpanForce)
+	if len(superseded) != 1 || superseded[0].OK || superseded[0].Status != "superseded by a newer force" {
+		t.Fatalf("force spans = %+v, want the first one superseded", superseded)
+	}
+	if got := attribute(superseded[0], "force.query"); got != "First" {
+		t.Errorf("force.query = %v, want First", got)
+	}
+
+	// A failed attempt keeps the force open, the answer ends it
+	conn.sendAction("1", "pick", `{"ok": false}`)
+	conn.expectResult()
+	conn.sendAction("2", "pick", `{"ok": true}`)
+	conn.expectResult()
+	waitFor(t, "action spans", func() bool { return len(spansNamed(tracer, SpanAction)) == 2 })
+
+	forces := spansNamed(tracer, SpanForce)
+	if len(forces) != 2 {
+		t.Fatalf("%d force spans, want 2", len(forces))
+	}
+	force := forces[1]
+	if !force.OK || force.Status != "answered" || attribute(force, "force.answer_id") != "2" {
+		t.Errorf("force span = %+v, want it answered by action 2", force)
+	}
+	for _, action := range spansNamed(tracer, SpanAction) {
+		if action.TraceID != force.TraceID || action.ParentID != force.SpanID {
+			t.Errorf("action span %v is not a child of the force span", attribute(action, "action.id"))
+		}
+		if got := attribute(action, "action.forced"); got != true {
+			t.Errorf("action.forced = %v, want true", got)
+		}
+	}
+}
+
+func TestInMemoryTracer(t *testing.T) {
+	tracer := NewInMemoryTracer()
+
+	ctx, parent := tracer.Start(context.Background(), "parent", Attr("a", 1))
+	_, child := tracer.Start(ctx, "child")
+	child.AddEvent("retry", Attr("attempt", 2))
+	child.SetStatus(false, "failed")
+	child.End()
+	child.End()
+	parent.SetAttributes(Attr("a", 2), Attr("b", "x"))
+	parent.End()
+
+	spans := tracer.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("%d spans recorded, want 2 (End twice must record once)", len(spans))
+	}
+	c, p := spans[0], spans[1]
+	if c.TraceID != p.TraceID || c.ParentID != p.SpanID || p.ParentID != "" {
+		t.Errorf("child %+v is not linked to parent %+v", c, p)
+	}
+	if c.OK || c.Status != "failed" || len(c.Events) != 1 || c.Events[0].Name != "retry" {
+		t.Errorf("child span = %+v", c)
+	}
+	if len(p.Attributes) != 2 || attribute(p, "a") != 2 || attribute(p, "b") != "x" {
+		t.Errorf("parent attributes = %v, want a=2 b=x", p.Attributes)
+	}
+	if got := tracer.Trace(p.TraceID); len(got) != 2 {
+		t.Errorf("Trace() returned %d spans, want 2", len(got))
+	}
+
+	var b bytes.Buffer
+	if err := tracer.WriteJSON(&b); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("WriteJSON wrote %d lines, want 2", len(lines))
+	}
+	var decoded SpanData
+	if err := json.Unmarshal([]byte(lines[1]), &decoded); err != nil || decoded.Name != "parent" {
+		t.Errorf("second line = %s, want the parent span", lines[1])
+	}
+
+	tracer.Reset()
+	if len(tracer.Spans()) != 0 {
+		t.Error("Reset() kept spans")
+	}
+}
@@ -0,0 +1,255 @@
+// Package turnbased runs turn-based games with Neuro on top of action windows.
+// Each of Neuro's turns registers a single move action whose schema lists the
+// legal moves, forces it with the rendered game state, waits for a legal move
+// and ends the window.
+package turnbased
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/cassitly/neuro-integration-sdk"
+)
+
+var (
+	// ErrGameOver is returned when a turn is requested after the game has ended
+	ErrGameOver = errors.New("game is over")
+	// ErrNoLegalMoves is returned when the game is not over but Neuro has no move to make
+	ErrNoLegalMoves = errors.New("no legal moves")
+	// ErrTooManyIllegalMoves is returned when Neuro keeps choosing illegal moves
+	ErrTooManyIllegalMoves = errors.New("too many illegal moves")
+)
+
+// Game is a turn-based game played by Neuro
+type Game interface {
+	// LegalMoves returns the moves Neuro can make this turn
+	LegalMoves() []string
+	// ApplyMove makes Neuro's move and advances the game to Neuro's next turn,
+	// e.g. by playing the opponent's move. The returned text is sent to Neuro
+	// as context (empty sends nothing).
+	ApplyMove(move string) (context string, err error)
+	// RenderState describes the game state, sent as the state of each force
+	RenderState() string
+	// IsOver reports whether the game has ended
+	IsOver() bool
+}
+
+// Config configures how turns are presented to Neuro
+type Config struct {
+	// ActionName is the name of the move action (default "make_move")
+	ActionName string
+	// Description is the description of the move action (default "Make your move")
+	Description string
+	// Param is the name of the move parameter (default "move")
+	Param string
+	// Query is sent with each force (default "It is your turn. Choose your move.")
+	Query string
+	// Priority is the priority of each force (default low)
+	Priority neuro.Priority
+	// EphemeralContext marks the query and state of each force as ephemeral
+	EphemeralContext bool
+	// MaxIllegalMoves is how many illegal moves are rejected in one turn before
+	// the turn fails with ErrTooManyIllegalMoves (default 3)
+	MaxIllegalMoves int
+	// SilentContext sends the context returned by ApplyMove as silent
+	SilentContext bool
+}
+
+// Runner plays a Game with Neuro through a client
+type Runner struct {
+	client *neuro.Client
+	game   Game
+	config Config
+}
+
+// New creates a runner for a game
+func New(client *neuro.Client, game Game, config Config) *Runner {
+	if config.ActionName == "" {
+		config.ActionName = "make_move"
+	}
+	if config.Description == "" {
+		config.Description = "Make your move"
+	}
+	if config.Param == "" {
+		config.Param = "move"
+	}
+	if config.Query == "" {
+		config.Query = "It is your turn. Choose your move."
+	}
+	if config.Priority == "" {
+		config.Priority = neuro.PriorityLow
+	}
+	if config.MaxIllegalMoves <= 0 {
+		config.MaxIllegalMoves = 3
+	}
+
+	return &Runner{
+		client: client,
+		game:   game,
+		config: config,
+	}
+}
+
+// Play runs turns until the game is over
+func (r *Runner) Play(ctx context.Context) error {
+	for !r.game.IsOver() {
+		if _, err := r.PlayTurn(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PlayTurn runs a single Neuro turn and returns the move that was made.
+// Illegal moves are rejected with a failure result, which makes Neuro retry
+// the forced action. The window is ended however the turn finishes.
+func (r *Runner) PlayTurn(ctx context.Context) (string, error) {
+	if r.game.IsOver() {
+		return "", ErrGameOver
+	}
+
+	moves := r.game.LegalMoves()
+	if len(moves) == 0 {
+		return "", ErrNoLegalMoves
+	}
+
+	turn := &moveAction{
+		runner: r,
+		moves:  moves,
+		legal:  make(map[string]bool, len(moves)),
+		done:   make(chan turnResult, 1),
+	}
+	for _, m := range moves {
+		turn.legal[m] = true
+	}
+
+	window := r.client.NewActionWindow().
+		AddAction(turn).
+		SetForce(r.config.Query,
+			neuro.WithState(r.game.RenderState()),
+			neuro.WithPriority(r.config.Priority),
+			neuro.WithEphemeralContext(r.config.EphemeralContext),
+		)
+	if err := window.Register(); err != nil {
+		return "", fmt.Errorf("failed to start turn: %w", err)
+	}
+	defer window.End()
+
+	select {
+	case res := <-turn.done:
+		if res.err != nil {
+			return res.move, res.err
+		}
+		if res.context != "" {
+			if err := r.client.SendContext(res.context, r.config.SilentContext); err != nil {
+				return res.move, fmt.Errorf("failed to send move context: %w", err)
+			}
+		}
+		return res.move, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// turnResult is how a turn ended
+type turnResult struct {
+	move    string
+	context string
+	err     error
+}
+
+// moveAction is the action registered for a single turn
+type moveAction struct {
+	runner *Runner
+	moves  []string
+	legal  map[string]bool
+	done   chan turnResult
+
+	mu       sync.Mutex
+	illegal  int
+	accepted bool
+}
+
+func (a *moveAction) GetName() string {
+	return a.runner.config.ActionName
+}
+
+func (a *moveAction) GetDescription() string {
+	return a.runner.config.Description
+}
+
+func (a *moveAction) GetSchema() *neuro.ActionSchema {
+	return neuro.WrapSchema(map[string]interface{}{
+		a.runner.config.Param: map[string]interface{}{
+			"type": "string",
+			"enum": a.moves,
+		},
+	}, []string{a.runner.config.Param})
+}
+
+func (a *moveAction) Validate(data json.RawMessage) (interface{}, neuro.ExecutionResult) {
+	var params map[string]interface{}
+	if err := neuro.ParseActionData(data, &params); err != nil {
+		return nil, a.reject("Invalid parameters")
+	}
+	move, _ := params[a.runner.config.Param].(string)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.accepted {
+		return nil, neuro.NewFailureResult("A move was already made this turn")
+	}
+	if !a.legal[move] {
+		a.illegal++
+		if a.illegal >= a.runner.config.MaxIllegalMoves {
+			a.finish(turnResult{err: fmt.Errorf("%w: last was %q", ErrTooManyIllegalMoves, move)})
+		}
+		return nil, neuro.NewFailureResult(fmt.Sprintf("%q is not a legal move. Legal moves: %s", move, strings.Join(a.moves, ", ")))
+	}
+
+	// The move is only reserved in Execute, since the result may still fail to send
+	return move, neuro.NewSuccessResult(fmt.Sprintf("Playing %s", move))
+}
+
+// reject counts an unparseable move as illegal. Must not be called with a.mu held.
+func (a *moveAction) reject(message string) neuro.ExecutionResult {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.illegal++
+	if a.illegal >= a.runner.config.MaxIllegalMoves {
+		a.finish(turnResult{err: ErrTooManyIllegalMoves})
+	}
+	return neuro.NewFailureResult(message)
+}
+
+func (a *moveAction) Execute(state interface{}) {
+	move := state.(string)
+
+	a.mu.Lock()
+	if a.accepted {
+		a.mu.Unlock()
+		return
+	}
+	a.accepted = true
+	a.mu.Unlock()
+
+	ctxText, err := a.runner.game.ApplyMove(move)
+	if err != nil {
+		err = fmt.Errorf("failed to apply move %q: %w", move, err)
+	}
+	a.finish(turnResult{move: move, context: ctxText, err: err})
+}
+
+// finish reports the end of the turn, keeping only the first result
+func (a *moveAction) finish(res turnResult) {
+	select {
+	case a.done <- res:
+	default:
+	}
+}
@@ -0,0 +1,250 @@
+package turnbased
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/cassitly/neuro-integration-sdk"
+)
+
+// testGame is a game of a fixed number of turns where Neuro picks "left" or "right"
+type testGame struct {
+	turns    int
+	played   []string
+	applyErr error
+}
+
+func (g *testGame) LegalMoves() []string { return []string{"left", "right"} }
+func (g *testGame) RenderState() string  { return "Turn " + string(rune('1'+len(g.played))) }
+func (g *testGame) IsOver() bool         { return len(g.played) >= g.turns }
+
+func (g *testGame) ApplyMove(move string) (string, error) {
+	if g.applyErr != nil {
+		return "", g.applyErr
+	}
+	g.played = append(g.played, move)
+	return "You went " + move, nil
+}
+
+// testConn is Neuro's end of the connection
+type testConn struct {
+	t    *testing.T
+	conn *websocket.Conn
+}
+
+// newTestClient connects a client to a test server and returns both ends after startup
+func newTestClient(t *testing.T) (*neuro.Client, *testConn) {
+	t.Helper()
+
+	conns := make(chan *websocket.Conn, 1)
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		conns <- conn
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := neuro.NewClient(neuro.ClientConfig{
+		Game:         "Test Game",
+		WebsocketURL: "ws" + strings.TrimPrefix(srv.URL, "http"),
+		LogHandler:   slog.NewTextHandler(io.Discard, nil),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	var conn *testConn
+	select {
+	case ws := <-conns:
+		t.Cleanup(func() { ws.Close() })
+		conn = &testConn{t: t, conn: ws}
+	case <-time.After(2 * time.Second):
+		t.Fatal("client did not connect")
+	}
+	conn.expect(neuro.CommandStartup)
+	return c, conn
+}
+
+// expect reads the next message and fails the test if it is not command
+func (c *testConn) expect(command string) neuro.Message {
+	c.t.Helper()
+
+	c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg neuro.Message
+	if err := c.conn.ReadJSON(&msg); err != nil {
+		c.t.Fatalf("failed to read message: %v", err)
+	}
+	if msg.Command != command {
+		c.t.Fatalf("got %s %s, want %s", msg.Command, msg.Data, command)
+	}
+	return msg
+}
+
+// move answers the force with a move and returns whether it was accepted
+func (c *testConn) move(id, move string) bool {
+	c.t.Helper()
+
+	data, _ := json.Marshal(map[string]string{"move": move})
+	err := c.conn.WriteJSON(map[string]interface{}{
+		"command": neuro.CommandAction,
+		"data":    neuro.IncomingAction{ID: id, Name: "make_move", Data: string(data)},
+	})
+	if err != nil {
+		c.t.Fatal(err)
+	}
+
+	var result neuro.ActionResultData
+	if err := json.Unmarshal(c.expect(neuro.CommandActionResult).Data, &result); err != nil {
+		c.t.Fatal(err)
+	}
+	if result.ID != id {
+		c.t.Fatalf("got result for %q, want %q", result.ID, id)
+	}
+	return result.Success
+}
+
+// turnOutcome is what PlayTurn returned
+type turnOutcome struct {
+	move string
+	err  error
+}
+
+// startTurn plays a turn in the background once its force has been sent
+func startTurn(t *testing.T, r *Runner, conn *testConn) <-chan turnOutcome {
+	t.Helper()
+
+	done := make(chan turnOutcome, 1)
+	go func() {
+		move, err := r.PlayTurn(context.Background())
+		done <- turnOutcome{move, err}
+	}()
+	conn.expect(neuro.CommandRegisterActions)
+	conn.expect(neuro.CommandForceActions)
+	return done
+}
+
+func TestPlayTurn(t *testing.T) {
+	tests := []struct {
+		name     string
+		moves    []string // all but the last are rejected
+		applyErr error
+		want     string
+		wantErr  error
+	}{
+		{"legal move", []string{"left"}, nil, "left", nil},
+		{"retry after illegal move", []string{"up", "right"}, nil, "right", nil},
+		{"too many illegal moves", []string{"up", "down", "sideways"}, nil, "", ErrTooManyIllegalMoves},
+		{"apply error", []string{"left"}, errors.New("board is on fire"), "left", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, conn := newTestClient(t)
+			game := &testGame{turns: 1, applyErr: tt.applyErr}
+			done := startTurn(t, New(c, game, Config{}), conn)
+
+			for i, move := range tt.moves {
+				accepted := conn.move(string(rune('a'+i)), move)
+				if last := i == len(tt.moves)-1; accepted != (last && tt.wantErr == nil) {
+					t.Fatalf("move %q accepted = %v", move, accepted)
+				}
+			}
+			if tt.applyErr == nil && tt.wantErr == nil {
+				conn.expect(neuro.CommandContext)
+			}
+			conn.expect(neuro.CommandUnregisterActions)
+
+			got := <-done
+			switch {
+			case tt.applyErr != nil:
+				if !errors.Is(got.err, tt.applyErr) {
+					t.Errorf("PlayTurn() error = %v, want %v", got.err, tt.applyErr)
+				}
+			case !errors.Is(got.err, tt.wantErr):
+				t.Errorf("PlayTurn() error = %v, want %v", got.err, tt.wantErr)
+			}
+			if got.move != tt.want {
+				t.Errorf("PlayTurn() = %q, want %q", got.move, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlay(t *testing.T) {
+	c, conn := newTestClient(t)
+	game := &testGame{turns: 3}
+	r := New(c, game, Config{})
+
+	done := make(chan error, 1)
+	go func() { done <- r.Play(context.Background()) }()
+
+	moves := []string{"left", "right", "left"}
+	for i, move := range moves {
+		conn.expect(neuro.CommandRegisterActions)
+		conn.expect(neuro.CommandForceActions)
+		if !conn.move(string(rune('a'+i)), move) {
+			t.Fatalf("move %q was rejected", move)
+		}
+		conn.expect(neuro.CommandContext)
+		conn.expect(neuro.CommandUnregisterActions)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("Play() = %v", err)
+	}
+	if strings.Join(game.played, " ") != "left right left" {
+		t.Errorf("played %v, want %v", game.played, moves)
+	}
+	if _, err := r.PlayTurn(context.Background()); !errors.Is(err, ErrGameOver) {
+		t.Errorf("PlayTurn() after the game = %v, want %v", err, ErrGameOver)
+	}
+}
+
+func TestMoveReservedOnExecute(t *testing.T) {
+	game := &testGame{turns: 1}
+	turn := &moveAction{
+		runner: New(nil, game, Config{}),
+		moves:  game.LegalMoves(),
+		legal:  map[string]bool{"left": true, "right": true},
+		done:   make(chan turnResult, 1),
+	}
+
+	// A validated move whose result was never sent does not block the turn
+	if _, result := turn.Validate(json.RawMessage(`{"move": "left"}`)); !result.Successful {
+		t.Fatalf("Validate() = %+v", result)
+	}
+	state, result := turn.Validate(json.RawMessage(`{"move": "right"}`))
+	if !result.Successful {
+		t.Fatalf("Validate() after an unexecuted move = %+v, want success", result)
+	}
+
+	turn.Execute(state)
+	turn.Execute("left")
+	if got := <-turn.done; got.move != "right" {
+		t.Errorf("turn ended with %q, want right", got.move)
+	}
+	if len(game.played) != 1 {
+		t.Errorf("played %v, want only the first executed move", game.played)
+	}
+	if _, result := turn.Validate(json.RawMessage(`{"move": "left"}`)); result.Successful {
+		t.Error("Validate() accepted a move after one was made")
+	}
+}